@@ -0,0 +1,176 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"io/ioutil"
+	"os"
+	"strconv"
+	"strings"
+)
+
+// userHZ is the unit of process times in /proc, fixed on every Linux
+// architecture we run on
+const userHZ = 100
+
+var (
+	buildInfoDesc = prometheus.NewDesc(
+		"ganesha_build_info",
+		"Version of the running ganesha",
+		[]string{"version", "git_describe", "git_head"}, nil,
+	)
+	startTimeDesc = prometheus.NewDesc(
+		"ganesha_start_time_seconds",
+		"Start time of the ganesha process since unix epoch in seconds",
+		nil, nil,
+	)
+	inGraceDesc = prometheus.NewDesc(
+		"ganesha_in_grace",
+		"Whether ganesha is in its NFSv4 grace period",
+		nil, nil,
+	)
+)
+
+var (
+	adminEnabled = kingpin.Flag("collector.admin", "Activate admin collector").Default("true").Bool()
+)
+
+// AdminCollector Collector for ganesha version and state
+type AdminCollector struct {
+	conn     *dbus.Conn
+	adminMgr dbus.AdminMgr
+	grace    bool
+	errors   prometheus.Counter
+}
+
+// NewAdminCollector creates a new collector, reporting the grace period
+// when grace is set
+func NewAdminCollector(conn *dbus.Conn, grace bool) AdminCollector {
+	return AdminCollector{
+		conn:     conn,
+		adminMgr: dbus.NewAdminMgr(conn),
+		grace:    grace,
+		errors:   newDBusErrorsCounter("admin"),
+	}
+}
+
+// Describe prometheus description
+func (ac AdminCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- buildInfoDesc
+	ch <- startTimeDesc
+	ch <- inGraceDesc
+	ac.errors.Describe(ch)
+}
+
+// CollectErrors sends the error counters
+func (ac AdminCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	ac.errors.Collect(ch)
+}
+
+// Update do the actual job
+func (ac AdminCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	version, err := ac.adminMgr.Version(ctx)
+	if err != nil {
+		countError(ac.errors, err, "GetAll(org.ganesha.nfsd.admin)")
+		return err
+	}
+	ch <- prometheus.MustNewConstMetric(
+		buildInfoDesc,
+		prometheus.GaugeValue,
+		1,
+		version.Release, version.GitDescribe, version.GitHead)
+
+	if ac.grace {
+		if err := ac.updateGrace(ctx, ch); err != nil {
+			return err
+		}
+	}
+
+	// ganesha does not tell its start time, it is only known when its
+	// process is visible to the exporter
+	pid, err := ac.conn.ServicePID(ctx)
+	if err != nil {
+		log.Debugf("ganesha process ID: %v", err)
+		return nil
+	}
+	startTime, err := processStartTime(pid)
+	if err != nil {
+		log.Debugf("ganesha start time: %v", err)
+		return nil
+	}
+	ch <- prometheus.MustNewConstMetric(startTimeDesc, prometheus.GaugeValue, startTime)
+	return nil
+}
+
+func (ac AdminCollector) updateGrace(ctx context.Context, ch chan<- prometheus.Metric) error {
+	inGrace, err := ac.adminMgr.InGrace(ctx)
+	switch {
+	case dbus.IsUnknownMethod(err):
+		log.Debugf("get_grace: %v", err)
+	case err != nil:
+		countError(ac.errors, err, "get_grace")
+		return err
+	default:
+		var value float64
+		if inGrace {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(inGraceDesc, prometheus.GaugeValue, value)
+	}
+	return nil
+}
+
+// processStartTime returns the start time of the process pid since unix
+// epoch in seconds
+func processStartTime(pid uint32) (float64, error) {
+	data, err := ioutil.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return 0, err
+	}
+	// the command name may contain spaces, fields are counted after it
+	i := bytes.LastIndexByte(data, ')')
+	if i < 0 {
+		return 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	// starttime is the 22nd field, the 20th after the command name
+	fields := strings.Fields(string(data[i+1:]))
+	if len(fields) < 20 {
+		return 0, fmt.Errorf("unexpected format of /proc/%d/stat", pid)
+	}
+	ticks, err := strconv.ParseUint(fields[19], 10, 64)
+	if err != nil {
+		return 0, err
+	}
+	bootTime, err := readBootTime()
+	if err != nil {
+		return 0, err
+	}
+	return float64(bootTime) + float64(ticks)/userHZ, nil
+}
+
+// readBootTime returns the boot time of the system since unix epoch in
+// seconds
+func readBootTime() (uint64, error) {
+	f, err := os.Open("/proc/stat")
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "btime" {
+			return strconv.ParseUint(fields[1], 10, 64)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, fmt.Errorf("no boot time in /proc/stat")
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	authRequestsDesc = prometheus.NewDesc(
+		"ganesha_auth_requests_total",
+		"Number of authentication and name mapping lookups",
+		[]string{"backend"}, nil,
+	)
+	authLatencyAvgDesc = prometheus.NewDesc(
+		"ganesha_auth_latency_avg_seconds",
+		"Average latency of authentication and name mapping lookups",
+		[]string{"backend"}, nil,
+	)
+	authLatencyMaxDesc = prometheus.NewDesc(
+		"ganesha_auth_latency_max_seconds",
+		"Maximum latency of authentication and name mapping lookups",
+		[]string{"backend"}, nil,
+	)
+	authLatencyMinDesc = prometheus.NewDesc(
+		"ganesha_auth_latency_min_seconds",
+		"Minimum latency of authentication and name mapping lookups",
+		[]string{"backend"}, nil,
+	)
+)
+
+var (
+	authEnabled = kingpin.Flag("collector.auth", "Activate authentication and name mapping collector").Default("true").Bool()
+)
+
+// AuthCollector Collector for ganesha authentication and name mapping lookups
+type AuthCollector struct {
+	statsMgr dbus.StatsMgr
+	errors   prometheus.Counter
+}
+
+// NewAuthCollector creates a new collector
+func NewAuthCollector(conn *dbus.Conn) AuthCollector {
+	return AuthCollector{
+		statsMgr: dbus.NewStatsMgr(conn),
+		errors:   newDBusErrorsCounter("auth"),
+	}
+}
+
+// Describe prometheus description
+func (ac AuthCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- authRequestsDesc
+	ch <- authLatencyAvgDesc
+	ch <- authLatencyMaxDesc
+	ch <- authLatencyMinDesc
+	ac.errors.Describe(ch)
+}
+
+// CollectErrors sends the error counters
+func (ac AuthCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	ac.errors.Collect(ch)
+}
+
+// Update do the actual job
+func (ac AuthCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	stats, err := ac.statsMgr.GetAuthStats(ctx)
+	if err != nil {
+		countError(ac.errors, err, "GetAuthStats")
+		return err
+	}
+	// ganesha answers with a false status while auth stats are disabled
+	if !stats.Status {
+		log.Debugf("GetAuthStats: %s", stats.Error)
+		return nil
+	}
+	for backend, stat := range map[string]dbus.AuthStat{
+		"name_mapping": stats.NameMapping,
+		"winbind":      stats.Winbind,
+		"gss":          stats.GSS,
+	} {
+		ch <- prometheus.MustNewConstMetric(
+			authRequestsDesc,
+			prometheus.CounterValue,
+			float64(stat.Total),
+			backend)
+		ch <- prometheus.MustNewConstMetric(
+			authLatencyAvgDesc,
+			prometheus.GaugeValue,
+			stat.Avg/1e3,
+			backend)
+		ch <- prometheus.MustNewConstMetric(
+			authLatencyMaxDesc,
+			prometheus.GaugeValue,
+			stat.Max/1e3,
+			backend)
+		ch <- prometheus.MustNewConstMetric(
+			authLatencyMinDesc,
+			prometheus.GaugeValue,
+			stat.Min/1e3,
+			backend)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"time"
+)
+
+var lastPollDesc = prometheus.NewDesc(
+	"ganesha_exporter_last_poll_timestamp_seconds",
+	"Time of the last completed poll of ganesha, when serving metrics from the cache",
+	nil, nil,
+)
+
+// CachedCollector polls a GaneshaCollector on a fixed interval in the
+// background, and serves the metrics of the last completed poll
+type CachedCollector struct {
+	collector GaneshaCollector
+	mu        sync.RWMutex
+	metrics   []prometheus.Metric
+	lastPoll  time.Time
+}
+
+// NewCachedCollector creates a new collector polling gc every interval
+func NewCachedCollector(gc GaneshaCollector, interval time.Duration) *CachedCollector {
+	c := &CachedCollector{collector: gc}
+	go c.run(interval)
+	return c
+}
+
+// Describe prometheus description
+func (c *CachedCollector) Describe(ch chan<- *prometheus.Desc) {
+	c.collector.Describe(ch)
+	ch <- lastPollDesc
+}
+
+// Collect sends the metrics of the last completed poll
+func (c *CachedCollector) Collect(ch chan<- prometheus.Metric) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if c.lastPoll.IsZero() {
+		return
+	}
+	for _, m := range c.metrics {
+		ch <- m
+	}
+	ch <- prometheus.MustNewConstMetric(
+		lastPollDesc,
+		prometheus.GaugeValue,
+		float64(c.lastPoll.UnixNano())/1e9)
+}
+
+func (c *CachedCollector) run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		c.poll(interval)
+		<-ticker.C
+	}
+}
+
+// poll collects every metric, a poll never lasts longer than timeout so
+// that polls do not pile up
+func (c *CachedCollector) poll(timeout time.Duration) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	ch := make(chan prometheus.Metric)
+	go func() {
+		c.collector.WithContext(ctx).Collect(ch)
+		close(ch)
+	}()
+	metrics := []prometheus.Metric{}
+	for m := range ch {
+		metrics = append(metrics, m)
+	}
+	c.mu.Lock()
+	c.metrics = metrics
+	c.lastPoll = time.Now()
+	c.mu.Unlock()
+}
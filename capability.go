@@ -0,0 +1,183 @@
+package main
+
+import (
+	"context"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"strings"
+	"sync"
+	"time"
+)
+
+const introspectTimeout = 10 * time.Second
+
+// graceMethod is optional, the admin collector only reports the grace
+// period when ganesha implements it
+const graceMethod = "org.ganesha.nfsd.admin.get_grace"
+
+var (
+	capabilityDesc = prometheus.NewDesc(
+		"ganesha_exporter_capability",
+		"Whether ganesha implements a D-Bus method used by the exporter",
+		[]string{"method"}, nil,
+	)
+)
+
+// collectorRequirements lists the methods every collector needs, a
+// requirement listing several methods is met by any of them
+var collectorRequirements = map[string][][]string{
+	"exports":   {{"org.ganesha.nfsd.exportmgr.ShowExports"}},
+	"clients":   {{"org.ganesha.nfsd.clientmgr.ShowClients"}},
+	"fullstats": {{"org.ganesha.nfsd.exportstats.GetFULLV3Stats", "org.ganesha.nfsd.exportstats.GetFULLV4Stats"}},
+	"global":    {{"org.ganesha.nfsd.exportstats.GetGlobalOPS", "org.ganesha.nfsd.exportstats.ShowFastOPS"}},
+	"mdcache":   {{"org.ganesha.nfsd.exportstats.ShowMDCache", "org.ganesha.nfsd.exportstats.ShowCacheInode"}},
+	"auth":      {{"org.ganesha.nfsd.exportstats.GetAuthStats"}},
+	"stats":     {{"org.ganesha.nfsd.exportstats.StatusStats"}},
+	"admin":     {{"org.freedesktop.DBus.Properties.GetAll"}},
+}
+
+// exportsFamilyMethods maps the protocol families of the exports collector
+// to the methods they are fetched with
+var exportsFamilyMethods = map[string][]string{
+	"nfsv3":   {"org.ganesha.nfsd.exportstats.GetNFSv3IO"},
+	"nfsv40":  {"org.ganesha.nfsd.exportstats.GetNFSv40IO"},
+	"nfsv41":  {"org.ganesha.nfsd.exportstats.GetNFSv41IO"},
+	"pnfsv41": {"org.ganesha.nfsd.exportstats.GetNFSv41Layouts"},
+	"nfsv42":  {"org.ganesha.nfsd.exportstats.GetNFSv42IO"},
+	"pnfsv42": {"org.ganesha.nfsd.exportstats.GetNFSv42Layouts"},
+	"9p":      {"org.ganesha.nfsd.exportstats.Get9pIO", "org.ganesha.nfsd.exportstats.Get9pTransOps"},
+	"mnt":     {"org.ganesha.nfsd.exportstats.GetTotalOPS"},
+	"nlm":     {"org.ganesha.nfsd.exportstats.GetTotalOPS"},
+	"rquota":  {"org.ganesha.nfsd.exportstats.GetTotalOPS"},
+}
+
+// clientsFamilyMethods maps the protocol families of the clients collector
+// to the methods they are fetched with
+var clientsFamilyMethods = map[string][]string{
+	"nfsv3":   {"org.ganesha.nfsd.clientstats.GetNFSv3IO"},
+	"nfsv40":  {"org.ganesha.nfsd.clientstats.GetNFSv40IO"},
+	"nfsv41":  {"org.ganesha.nfsd.clientstats.GetNFSv41IO"},
+	"pnfsv41": {"org.ganesha.nfsd.clientstats.GetNFSv41Layouts"},
+	"nfsv42":  {"org.ganesha.nfsd.clientstats.GetNFSv42IO"},
+	"pnfsv42": {"org.ganesha.nfsd.clientstats.GetNFSv42Layouts"},
+	"mnt":     {"org.ganesha.nfsd.clientstats.GetClientAllops"},
+	"nlm":     {"org.ganesha.nfsd.clientstats.GetClientAllops"},
+	"rquota":  {"org.ganesha.nfsd.clientstats.GetClientAllops"},
+}
+
+// discoverCapabilities introspects the ganesha behind conn, nil is
+// returned when it cannot be introspected
+func discoverCapabilities(logger log.Logger, conn *dbus.Conn) dbus.Capabilities {
+	ctx, cancel := context.WithTimeout(context.Background(), introspectTimeout)
+	defer cancel()
+	caps, err := conn.Introspect(ctx)
+	if err != nil {
+		logger.Warnf("discovering ganesha capabilities failed, every collector is enabled: %v", err)
+		return nil
+	}
+	return caps
+}
+
+// rediscoverCapabilities retries discovering the capabilities of the
+// ganesha behind conn every time it appears on the bus, until it succeeds.
+// setup is then called once with them
+func rediscoverCapabilities(logger log.Logger, conn *dbus.Conn, setup func(caps dbus.Capabilities)) {
+	mu := sync.Mutex{}
+	done := false
+	conn.OnConnect(func() {
+		mu.Lock()
+		defer mu.Unlock()
+		if done {
+			return
+		}
+		caps := discoverCapabilities(logger, conn)
+		if caps == nil {
+			return
+		}
+		logger.Infof("discovered ganesha capabilities")
+		done = true
+		setup(caps)
+	})
+}
+
+// restrictProtocols disables the families of p fetched with a method
+// ganesha does not implement
+func restrictProtocols(logger log.Logger, collector string, p *protocols, methods map[string][]string, caps dbus.Capabilities) {
+	for family, enabled := range p.families() {
+		for _, method := range methods[family] {
+			if *enabled && !caps[method] {
+				logger.Infof("skipping %s stats of %s collector, ganesha does not implement %s", family, collector, method)
+				*enabled = false
+			}
+		}
+	}
+}
+
+// restrictCollectors removes the collectors needing a method ganesha does
+// not implement
+func restrictCollectors(logger log.Logger, collectors map[string]Collector, caps dbus.Capabilities) {
+	for name := range collectors {
+		for _, methods := range collectorRequirements[name] {
+			if !implementsAny(caps, methods) {
+				logger.Infof("skipping %s collector, ganesha does not implement %s", name, strings.Join(methods, " nor "))
+				delete(collectors, name)
+				break
+			}
+		}
+	}
+}
+
+// implements tells whether ganesha implements method, which is assumed
+// when its capabilities are unknown
+func implements(caps dbus.Capabilities, method string) bool {
+	return caps == nil || caps[method]
+}
+
+// implementsAny tells whether caps holds any of methods
+func implementsAny(caps dbus.Capabilities, methods []string) bool {
+	for _, method := range methods {
+		if caps[method] {
+			return true
+		}
+	}
+	return false
+}
+
+// capabilityCollector reports which of the methods used by the exporter
+// ganesha implements
+type capabilityCollector struct {
+	caps dbus.Capabilities
+}
+
+// Describe prometheus description
+func (cc capabilityCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- capabilityDesc
+}
+
+// Collect sends one series for every method used by the exporter
+func (cc capabilityCollector) Collect(ch chan<- prometheus.Metric) {
+	methods := map[string]bool{}
+	for _, requirements := range collectorRequirements {
+		for _, alternatives := range requirements {
+			for _, method := range alternatives {
+				methods[method] = true
+			}
+		}
+	}
+	for _, families := range []map[string][]string{exportsFamilyMethods, clientsFamilyMethods} {
+		for _, familyMethods := range families {
+			for _, method := range familyMethods {
+				methods[method] = true
+			}
+		}
+	}
+	methods[graceMethod] = true
+	for method := range methods {
+		var implemented float64
+		if cc.caps[method] {
+			implemented = 1
+		}
+		ch <- prometheus.MustNewConstMetric(capabilityDesc, prometheus.GaugeValue, implemented, method)
+	}
+}
@@ -1,12 +1,25 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/Gandi/ganesha_exporter/dbus"
 	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/alecthomas/kingpin.v2"
+	"strconv"
 )
 
 var (
+	clientInfoDesc = prometheus.NewDesc(
+		"ganesha_client_info",
+		"Protocols the client is active on",
+		[]string{"clientip", "nfsv3", "mntv3", "nlmv4", "rquota", "nfsv40", "nfsv41", "nfsv42", "plan9"}, nil,
+	)
+	clientLastActivityDesc = prometheus.NewDesc(
+		"ganesha_client_last_activity_timestamp_seconds",
+		"Time of the last activity of the client",
+		[]string{"clientip"}, nil,
+	)
 	clientsNfsV3RequestedDesc = prometheus.NewDesc(
 		"ganesha_clients_nfs_v3_requested_bytes_total",
 		"Number of requested bytes for NFSv3 operations",
@@ -97,6 +110,16 @@ var (
 		"Cumulative time spent in rpc wait queue for NFSv4.1",
 		[]string{"direction", "clientip"}, nil,
 	)
+	clientsNfsV41OpsDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v41_ops_total",
+		"Number of NFSv4.1 operations, Gandi specific",
+		[]string{"op", "clientip"}, nil,
+	)
+	clientsNfsV41OpsErrorsDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v41_ops_errors_total",
+		"Number of NFSv4.1 operations in error, Gandi specific",
+		[]string{"op", "clientip"}, nil,
+	)
 	clientsPnfsLayoutOperationsDesc = prometheus.NewDesc(
 		"ganesha_clients_pnfs_v41_layout_operations_total",
 		"Numer of layout operations for pNFSv4.1",
@@ -112,314 +135,757 @@ var (
 		"Cumulative delay time for pNFSv4.1",
 		[]string{"direction", "clientip"}, nil,
 	)
+	clientsNfsV42RequestedDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v42_requested_bytes_total",
+		"Number of requested bytes for NFSv4.2 operations",
+		[]string{"direction", "clientip"}, nil,
+	)
+	clientsNfsV42TransferedDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v42_transfered_bytes_total",
+		"Number of transfered bytes for NFSv4.2 operations",
+		[]string{"direction", "clientip"}, nil,
+	)
+	clientsNfsV42OperationsDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v42_operations_total",
+		"Number of operations for NFSv4.2",
+		[]string{"direction", "clientip"}, nil,
+	)
+	clientsNfsV42ErrorsDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v42_operations_errors_total",
+		"Number of operations in error for NFSv4.2",
+		[]string{"direction", "clientip"}, nil,
+	)
+	clientsNfsV42LatencyDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v42_operations_latency_seconds_total",
+		"Cumulative time consumed by operations for NFSv4.2",
+		[]string{"direction", "clientip"}, nil,
+	)
+	clientsNfsV42QueueWaitDesc = prometheus.NewDesc(
+		"ganesha_clients_nfs_v42_operations_queue_wait_seconds_total",
+		"Cumulative time spent in rpc wait queue for NFSv4.2",
+		[]string{"direction", "clientip"}, nil,
+	)
+	clientsPnfsV42LayoutOperationsDesc = prometheus.NewDesc(
+		"ganesha_clients_pnfs_v42_layout_operations_total",
+		"Numer of layout operations for pNFSv4.2",
+		[]string{"type", "clientip"}, nil,
+	)
+	clientsPnfsV42LayoutErrorsDesc = prometheus.NewDesc(
+		"ganesha_clients_pnfs_v42_layout_operations_errors_total",
+		"Numer of layout operations in error for pNFSv4.2",
+		[]string{"type", "clientip"}, nil,
+	)
+	clientsPnfsV42LayoutDelayDesc = prometheus.NewDesc(
+		"ganesha_clients_pnfs_v42_layout_delay_seconds_total",
+		"Cumulative delay time for pNFSv4.2",
+		[]string{"type", "clientip"}, nil,
+	)
+	clientsMntOperationsDesc = prometheus.NewDesc(
+		"ganesha_clients_mnt_operations_total",
+		"Number of MNT operations",
+		[]string{"operation", "clientip"}, nil,
+	)
+	clientsMntErrorsDesc = prometheus.NewDesc(
+		"ganesha_clients_mnt_operations_errors_total",
+		"Number of MNT operations in error",
+		[]string{"operation", "clientip"}, nil,
+	)
+	clientsNlmOperationsDesc = prometheus.NewDesc(
+		"ganesha_clients_nlm_operations_total",
+		"Number of NLM operations",
+		[]string{"operation", "clientip"}, nil,
+	)
+	clientsNlmErrorsDesc = prometheus.NewDesc(
+		"ganesha_clients_nlm_operations_errors_total",
+		"Number of NLM operations in error",
+		[]string{"operation", "clientip"}, nil,
+	)
+	clientsRquotaOperationsDesc = prometheus.NewDesc(
+		"ganesha_clients_rquota_operations_total",
+		"Number of RQUOTA operations",
+		[]string{"operation", "clientip"}, nil,
+	)
+	clientsRquotaErrorsDesc = prometheus.NewDesc(
+		"ganesha_clients_rquota_operations_errors_total",
+		"Number of RQUOTA operations in error",
+		[]string{"operation", "clientip"}, nil,
+	)
+)
+
+var (
+	clientsEnabled = kingpin.Flag("collector.clients", "Activate clients collector").Default("true").Bool()
+	clientsNFSv3   = kingpin.Flag("collector.clients.nfsv3", "Activate NFSv3 stats").Default("true").Bool()
+	clientsNFSv40  = kingpin.Flag("collector.clients.nfsv40", "Activate NFSv4.0 stats").Default("true").Bool()
+	clientsNFSv41  = kingpin.Flag("collector.clients.nfsv41", "Activate NFSv4.1 stats").Default("true").Bool()
+	clientsPNFSv41 = kingpin.Flag("collector.clients.pnfsv41", "Activate pNFSv4.1 stats").Default("true").Bool()
+	clientsNFSv42  = kingpin.Flag("collector.clients.nfsv42", "Activate NFSv4.2 stats").Default("true").Bool()
+	clientsPNFSv42 = kingpin.Flag("collector.clients.pnfsv42", "Activate pNFSv4.2 stats, ganesha must provide GetNFSv42Layouts").Default("false").Bool()
+	clientsMNT     = kingpin.Flag("collector.clients.mnt", "Activate MNT stats").Default("true").Bool()
+	clientsNLM     = kingpin.Flag("collector.clients.nlm", "Activate NLM stats").Default("true").Bool()
+	clientsRQUOTA  = kingpin.Flag("collector.clients.rquota", "Activate RQUOTA stats").Default("true").Bool()
 )
 
+// clientStats holds the statistics of one client, a zero value is kept for
+// the protocol families that are disabled or not served
+type clientStats struct {
+	nfsv3, nfsv40, nfsv41, nfsv42             dbus.BasicStats
+	pnfsv41, pnfsv42                          dbus.PNFSOperations
+	nfsv3Err, nfsv40Err, nfsv41Err, nfsv42Err error
+	pnfsv41Err, pnfsv42Err                    error
+	ops                                       dbus.AllOperations
+	opsErr                                    error
+}
+
 // ClientsCollector Collector for ganesha clients
 type ClientsCollector struct {
-	clientMgr                      dbus.ClientMgr
-	nfsv3, nfsv40, nfsv41, pnfsv41 *bool
+	clientMgr dbus.ClientMgr
+	protocols
+	activeFilter
+	sampleTime
+	workers int
+	errors  prometheus.Counter
+}
+
+// clientsProtocols returns the protocol families enabled by flags
+func clientsProtocols() protocols {
+	return protocols{
+		nfsv3:   *clientsNFSv3,
+		nfsv40:  *clientsNFSv40,
+		nfsv41:  *clientsNFSv41,
+		pnfsv41: *clientsPNFSv41,
+		nfsv42:  *clientsNFSv42,
+		pnfsv42: *clientsPNFSv42,
+		mnt:     *clientsMNT,
+		nlm:     *clientsNLM,
+		rquota:  *clientsRQUOTA,
+	}
 }
 
-// NewClientsCollector creates a new collector
-func NewClientsCollector() ClientsCollector {
+// NewClientsCollector creates a new collector gathering the protocol families in p,
+// with at most workers concurrent calls. With activeOnly, the families a client
+// is not active on are left out, and with timestamps the metrics carry the time
+// ganesha sampled them
+func NewClientsCollector(conn *dbus.Conn, p protocols, workers int, activeOnly, timestamps bool) ClientsCollector {
 	return ClientsCollector{
-		clientMgr: dbus.NewClientMgr(),
-		nfsv3:     kingpin.Flag("collector.clients.nfsv3", "Activate NFSv3 stats").Default("true").Bool(),
-		nfsv40:    kingpin.Flag("collector.clients.nfsv40", "Activate NFSv4.0 stats").Default("true").Bool(),
-		nfsv41:    kingpin.Flag("collector.clients.nfsv41", "Activate NFSv4.1 stats").Default("true").Bool(),
-		pnfsv41:   kingpin.Flag("collector.clients.pnfsv41", "Activate pNFSv4.1 stats").Default("true").Bool(),
+		clientMgr:    dbus.NewClientMgr(conn),
+		protocols:    p,
+		activeFilter: newActiveFilter(activeOnly, "clients"),
+		workers:      workers,
+		sampleTime:   sampleTime(timestamps),
+		errors:       newDBusErrorsCounter("clients"),
 	}
 }
 
 // Describe prometheus description
 func (ic ClientsCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(ic, ch)
+	ch <- clientInfoDesc
+	ch <- clientLastActivityDesc
+	ch <- clientsNfsV3RequestedDesc
+	ch <- clientsNfsV3TransferedDesc
+	ch <- clientsNfsV3OperationsDesc
+	ch <- clientsNfsV3ErrorsDesc
+	ch <- clientsNfsV3LatencyDesc
+	ch <- clientsNfsV3QueueWaitDesc
+	ch <- clientsNfsV40RequestedDesc
+	ch <- clientsNfsV40TransferedDesc
+	ch <- clientsNfsV40OperationsDesc
+	ch <- clientsNfsV40ErrorsDesc
+	ch <- clientsNfsV40LatencyDesc
+	ch <- clientsNfsV40QueueWaitDesc
+	ch <- clientsNfsV41RequestedDesc
+	ch <- clientsNfsV41TransferedDesc
+	ch <- clientsNfsV41OperationsDesc
+	ch <- clientsNfsV41ErrorsDesc
+	ch <- clientsNfsV41LatencyDesc
+	ch <- clientsNfsV41QueueWaitDesc
+	ch <- clientsNfsV41OpsDesc
+	ch <- clientsNfsV41OpsErrorsDesc
+	ch <- clientsPnfsLayoutOperationsDesc
+	ch <- clientsPnfsLayoutErrorsDesc
+	ch <- clientsPnfsLayoutDelayDesc
+	ch <- clientsNfsV42RequestedDesc
+	ch <- clientsNfsV42TransferedDesc
+	ch <- clientsNfsV42OperationsDesc
+	ch <- clientsNfsV42ErrorsDesc
+	ch <- clientsNfsV42LatencyDesc
+	ch <- clientsNfsV42QueueWaitDesc
+	ch <- clientsPnfsV42LayoutOperationsDesc
+	ch <- clientsPnfsV42LayoutErrorsDesc
+	ch <- clientsPnfsV42LayoutDelayDesc
+	ch <- clientsMntOperationsDesc
+	ch <- clientsMntErrorsDesc
+	ch <- clientsNlmOperationsDesc
+	ch <- clientsNlmErrorsDesc
+	ch <- clientsRquotaOperationsDesc
+	ch <- clientsRquotaErrorsDesc
+	ic.errors.Describe(ch)
+	ic.statsErrors.Describe(ch)
+}
+
+// CollectErrors sends the error counters
+func (ic ClientsCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	ic.errors.Collect(ch)
+	ic.statsErrors.Collect(ch)
 }
 
-// Collect do the actual job
-func (ic ClientsCollector) Collect(ch chan<- prometheus.Metric) {
-	var ()
-	_, clients := ic.clientMgr.ShowClients()
-	for _, client := range clients {
+// Update do the actual job
+func (ic ClientsCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	_, clients, err := ic.clientMgr.ShowClients(ctx)
+	if err != nil {
+		countError(ic.errors, err, "ShowClients")
+		return err
+	}
+	results := make([]clientStats, len(clients))
+	parallel(ic.workers, len(clients), func(i int) {
+		results[i] = ic.fetch(ctx, clients[i])
+	})
+	for i, client := range clients {
+		result := results[i]
 		clientip := client.Client
-		if *ic.nfsv3 {
-			var stats dbus.BasicStats
-			if client.NFSv3 {
-				stats = ic.clientMgr.GetNFSv3IO(client.Client)
+		ch <- prometheus.MustNewConstMetric(
+			clientInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			clientip,
+			strconv.FormatBool(client.NFSv3), strconv.FormatBool(client.MNTv3),
+			strconv.FormatBool(client.NLMv4), strconv.FormatBool(client.RQUOTA),
+			strconv.FormatBool(client.NFSv40), strconv.FormatBool(client.NFSv41),
+			strconv.FormatBool(client.NFSv42), strconv.FormatBool(client.Plan9))
+		ch <- prometheus.MustNewConstMetric(
+			clientLastActivityDesc,
+			prometheus.GaugeValue,
+			float64(client.LastTime.Nano())/1e9,
+			clientip)
+		if ic.nfsv3 {
+			stats, err := result.nfsv3, result.nfsv3Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv3IO(%s)", client.Client))
+			} else if ic.emit(client.NFSv3, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV3QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", clientip))
+			}
+		}
+		if ic.nfsv40 {
+			stats, err := result.nfsv40, result.nfsv40Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv40IO(%s)", client.Client))
+			} else if ic.emit(client.NFSv40, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV40QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", clientip))
 			}
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Requested),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Transfered),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Total),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Errors),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Latency)/1e9,
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.QueueWait)/1e9,
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Requested),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Transfered),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Total),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Errors),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Latency)/1e9,
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV3QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.QueueWait)/1e9,
-				"write", clientip)
 		}
-		if *ic.nfsv40 {
-			stats := dbus.BasicStats{}
-			if client.NFSv40 {
-				stats = ic.clientMgr.GetNFSv40IO(client.Client)
+		if ic.nfsv41 {
+			stats, err := result.nfsv41, result.nfsv41Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv41IO(%s)", client.Client))
+			} else if ic.emit(client.NFSv41, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV41QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", clientip))
+				if stats.Extended {
+					for _, op := range []struct {
+						name string
+						stat dbus.OperationStat
+					}{
+						{"open", stats.Open},
+						{"close", stats.Close},
+						{"getattr", stats.Getattr},
+						{"lock", stats.Lock},
+					} {
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsNfsV41OpsDesc,
+							prometheus.CounterValue,
+							float64(op.stat.Total),
+							op.name, clientip))
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsNfsV41OpsErrorsDesc,
+							prometheus.CounterValue,
+							float64(op.stat.Errors),
+							op.name, clientip))
+					}
+				}
 			}
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Requested),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Transfered),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Total),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Errors),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Latency)/1e9,
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.QueueWait)/1e9,
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Requested),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Transfered),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Total),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Errors),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Latency)/1e9,
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV40QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.QueueWait)/1e9,
-				"write", clientip)
 		}
-		if *ic.nfsv41 {
-			stats := dbus.BasicStats{}
-			if client.NFSv41 {
-				stats = ic.clientMgr.GetNFSv41IO(client.Client)
+		if ic.pnfsv41 {
+			stats, err := result.pnfsv41, result.pnfsv41Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv41Layouts(%s)", client.Client))
+			} else if ic.emit(client.NFSv41, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Total),
+					"getdevinfo", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Errors),
+					"getdevinfo", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Delays)/1e9,
+					"getdevinfo", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Total),
+					"get", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Errors),
+					"get", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Delays)/1e9,
+					"get", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Total),
+					"commit", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Errors),
+					"commit", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Delays)/1e9,
+					"commit", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Total),
+					"return", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Errors),
+					"return", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Delays)/1e9,
+					"return", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Total),
+					"recall", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Errors),
+					"recall", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Delays)/1e9,
+					"recall", clientip))
 			}
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Requested),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Transfered),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Total),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Errors),
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Latency)/1e9,
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.QueueWait)/1e9,
-				"read", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Requested),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Transfered),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Total),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Errors),
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Latency)/1e9,
-				"write", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsNfsV41QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.QueueWait)/1e9,
-				"write", clientip)
 		}
-		if *ic.pnfsv41 {
-			stats := dbus.PNFSOperations{}
-			if client.NFSv41 {
-				stats = ic.clientMgr.GetNFSv41Layouts(client.Client)
+		if ic.nfsv42 {
+			stats, err := result.nfsv42, result.nfsv42Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv42IO(%s)", client.Client))
+			} else if ic.emit(client.NFSv42, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsNfsV42QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", clientip))
 			}
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Getdevinfo.Total),
-				"getdevinfo", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Getdevinfo.Errors),
-				"getdevinfo", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.Getdevinfo.Delays)/1e9,
-				"getdevinfo", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutGet.Total),
-				"get", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutGet.Errors),
-				"get", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutGet.Delays)/1e9,
-				"get", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutCommit.Total),
-				"commit", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutCommit.Errors),
-				"commit", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutCommit.Delays)/1e9,
-				"commit", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutReturn.Total),
-				"return", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutReturn.Errors),
-				"return", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutReturn.Delays)/1e9,
-				"return", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutRecall.Total),
-				"recall", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutRecall.Errors),
-				"recall", clientip)
-			ch <- prometheus.MustNewConstMetric(
-				clientsPnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutRecall.Delays)/1e9,
-				"recall", clientip)
 		}
+		if ic.pnfsv42 {
+			stats, err := result.pnfsv42, result.pnfsv42Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv42Layouts(%s)", client.Client))
+			} else if ic.emit(client.NFSv42, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Total),
+					"getdevinfo", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Errors),
+					"getdevinfo", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Delays)/1e9,
+					"getdevinfo", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Total),
+					"get", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Errors),
+					"get", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Delays)/1e9,
+					"get", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Total),
+					"commit", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Errors),
+					"commit", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Delays)/1e9,
+					"commit", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Total),
+					"return", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Errors),
+					"return", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Delays)/1e9,
+					"return", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Total),
+					"recall", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Errors),
+					"recall", clientip))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					clientsPnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Delays)/1e9,
+					"recall", clientip))
+			}
+		}
+		if ic.mnt || ic.nlm || ic.rquota {
+			stats, err := result.ops, result.opsErr
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetClientAllops(%s)", client.Client))
+			} else if ic.emit(client.MNTv3 || client.NLMv4 || client.RQUOTA, stats.StatsBaseAnswer) {
+				if ic.mnt && ic.active(client.MNTv3) {
+					for _, op := range stats.MNT {
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsMntOperationsDesc,
+							prometheus.CounterValue,
+							float64(op.Total),
+							op.Name, clientip))
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsMntErrorsDesc,
+							prometheus.CounterValue,
+							float64(op.Errors),
+							op.Name, clientip))
+					}
+				}
+				if ic.nlm && ic.active(client.NLMv4) {
+					for _, op := range stats.NLMv4 {
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsNlmOperationsDesc,
+							prometheus.CounterValue,
+							float64(op.Total),
+							op.Name, clientip))
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsNlmErrorsDesc,
+							prometheus.CounterValue,
+							float64(op.Errors),
+							op.Name, clientip))
+					}
+				}
+				if ic.rquota && ic.active(client.RQUOTA) {
+					for _, op := range stats.RQUOTA {
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsRquotaOperationsDesc,
+							prometheus.CounterValue,
+							float64(op.Total),
+							op.Name, clientip))
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							clientsRquotaErrorsDesc,
+							prometheus.CounterValue,
+							float64(op.Errors),
+							op.Name, clientip))
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// fetch gets the statistics of every enabled protocol family served by client
+func (ic ClientsCollector) fetch(ctx context.Context, client dbus.Client) clientStats {
+	result := clientStats{}
+	if ic.nfsv3 && client.NFSv3 {
+		result.nfsv3, result.nfsv3Err = ic.clientMgr.GetNFSv3IO(ctx, client.Client)
+	}
+	if ic.nfsv40 && client.NFSv40 {
+		result.nfsv40, result.nfsv40Err = ic.clientMgr.GetNFSv40IO(ctx, client.Client)
+	}
+	if ic.nfsv41 && client.NFSv41 {
+		result.nfsv41, result.nfsv41Err = ic.clientMgr.GetNFSv41IO(ctx, client.Client)
+	}
+	if ic.pnfsv41 && client.NFSv41 {
+		result.pnfsv41, result.pnfsv41Err = ic.clientMgr.GetNFSv41Layouts(ctx, client.Client)
+	}
+	if ic.nfsv42 && client.NFSv42 {
+		result.nfsv42, result.nfsv42Err = ic.clientMgr.GetNFSv42IO(ctx, client.Client)
+	}
+	if ic.pnfsv42 && client.NFSv42 {
+		result.pnfsv42, result.pnfsv42Err = ic.clientMgr.GetNFSv42Layouts(ctx, client.Client)
+	}
+	if (ic.mnt && client.MNTv3) || (ic.nlm && client.NLMv4) || (ic.rquota && client.RQUOTA) {
+		result.ops, result.opsErr = ic.clientMgr.GetClientAllops(ctx, client.Client)
 	}
+	return result
 }
@@ -0,0 +1,298 @@
+package main
+
+import (
+	"context"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"golang.org/x/sys/unix"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"sync"
+	"time"
+)
+
+var (
+	collectorWorkers    = kingpin.Flag("collector.workers", "Maximum number of concurrent D-Bus calls per collector").Default("8").Int()
+	collectorActiveOnly = kingpin.Flag("collector.active-only", "Only emit the statistics of the protocols an export or client is active on, and that ganesha answered").Default("false").Bool()
+	collectorSampleTime = kingpin.Flag("collector.sample-timestamp", "Timestamp the exports and clients metrics with the time ganesha sampled the statistics").Default("false").Bool()
+)
+
+var (
+	upDesc = prometheus.NewDesc(
+		"ganesha_up",
+		"Whether the ganesha service is present on the bus",
+		nil, nil,
+	)
+	reconnectsDesc = prometheus.NewDesc(
+		"ganesha_exporter_dbus_reconnects_total",
+		"Number of times the D-Bus connection has been re-established",
+		nil, nil,
+	)
+	scrapeDurationDesc = prometheus.NewDesc(
+		"ganesha_exporter_collector_duration_seconds",
+		"Duration of a collector scrape",
+		[]string{"collector"}, nil,
+	)
+	scrapeSuccessDesc = prometheus.NewDesc(
+		"ganesha_exporter_collector_success",
+		"Whether a collector succeeded",
+		[]string{"collector"}, nil,
+	)
+)
+
+// protocols selects the protocol families a collector gathers
+type protocols struct {
+	nfsv3, nfsv40, nfsv41, pnfsv41, nfsv42, pnfsv42, plan9 bool
+	mnt, nlm, rquota                                       bool
+}
+
+// families maps the name of every protocol family to its switch
+func (p *protocols) families() map[string]*bool {
+	return map[string]*bool{
+		"nfsv3":   &p.nfsv3,
+		"nfsv40":  &p.nfsv40,
+		"nfsv41":  &p.nfsv41,
+		"pnfsv41": &p.pnfsv41,
+		"nfsv42":  &p.nfsv42,
+		"pnfsv42": &p.pnfsv42,
+		"9p":      &p.plan9,
+		"mnt":     &p.mnt,
+		"nlm":     &p.nlm,
+		"rquota":  &p.rquota,
+	}
+}
+
+// activeFilter selects the protocol families whose statistics are emitted
+// for an export or client, and counts the errors ganesha answered
+type activeFilter struct {
+	activeOnly  bool
+	statsErrors *prometheus.CounterVec
+}
+
+// newActiveFilter creates a new filter for the named collector, emitting
+// every family unless activeOnly is set
+func newActiveFilter(activeOnly bool, collector string) activeFilter {
+	return activeFilter{
+		activeOnly: activeOnly,
+		statsErrors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name:        "ganesha_exporter_stats_errors_total",
+			Help:        "Number of statistics calls ganesha answered with an error, by error message",
+			ConstLabels: prometheus.Labels{"collector": collector},
+		}, []string{"error"}),
+	}
+}
+
+// active tells whether to emit a family depending on whether it is served
+func (f activeFilter) active(served bool) bool {
+	return !f.activeOnly || served
+}
+
+// emit tells whether to emit the statistics of a family from the answer
+// to its call, counting the error of a failed answer. The answer of a
+// family that is not served is the zero value, as it is never fetched
+func (f activeFilter) emit(served bool, answer dbus.StatsBaseAnswer) bool {
+	if served && !answer.Status {
+		f.statsErrors.WithLabelValues(answer.Error).Inc()
+	}
+	return f.active(served && answer.Status)
+}
+
+// sampleTime tells whether to timestamp metrics with the time ganesha
+// sampled the statistics, rather than with the time of the scrape
+type sampleTime bool
+
+// stamp returns m, timestamped with t when enabled. An answer with an
+// error carries no time, its metrics are left untouched
+func (s sampleTime) stamp(t unix.Timespec, m prometheus.Metric) prometheus.Metric {
+	if !s || t.Nano() == 0 {
+		return m
+	}
+	return prometheus.NewMetricWithTimestamp(time.Unix(t.Unix()), m)
+}
+
+// Collector is the interface implemented by every ganesha collector
+type Collector interface {
+	// Describe sends the descriptions of every metric the collector may emit
+	Describe(ch chan<- *prometheus.Desc)
+	// Update sends the current metrics, an error means the collection failed.
+	// When ctx is done, the metrics already fetched are still sent
+	Update(ctx context.Context, ch chan<- prometheus.Metric) error
+	// CollectErrors sends the counters of the errors met by the collector,
+	// they are sent even when the collector is not run
+	CollectErrors(ch chan<- prometheus.Metric)
+}
+
+// GaneshaCollector runs the enabled collectors and reports their health
+type GaneshaCollector struct {
+	ctx      context.Context
+	conn     *dbus.Conn
+	set      *collectorSet
+	timeouts *prometheus.CounterVec
+}
+
+// collectorSet holds the collectors run by a GaneshaCollector, which are
+// replaced when the capabilities of ganesha are discovered late
+type collectorSet struct {
+	mu         sync.RWMutex
+	collectors map[string]Collector
+}
+
+// NewGaneshaCollector creates a new collector wrapping collectors
+func NewGaneshaCollector(conn *dbus.Conn, collectors map[string]Collector) GaneshaCollector {
+	timeouts := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ganesha_exporter_collector_timeouts_total",
+		Help: "Number of collector scrapes cut short by the scrape timeout",
+	}, []string{"collector"})
+	for name := range collectors {
+		timeouts.WithLabelValues(name)
+	}
+	return GaneshaCollector{
+		ctx:      context.Background(),
+		conn:     conn,
+		set:      &collectorSet{collectors: collectors},
+		timeouts: timeouts,
+	}
+}
+
+// SetCollectors replaces the collectors run by gc. They must be a subset
+// of the collectors it was created with, whose descriptions are registered
+func (gc GaneshaCollector) SetCollectors(collectors map[string]Collector) {
+	gc.set.mu.Lock()
+	defer gc.set.mu.Unlock()
+	for name := range gc.set.collectors {
+		if _, ok := collectors[name]; !ok {
+			gc.timeouts.DeleteLabelValues(name)
+		}
+	}
+	gc.set.collectors = collectors
+}
+
+// Collectors returns the collectors run by gc
+func (gc GaneshaCollector) Collectors() map[string]Collector {
+	gc.set.mu.RLock()
+	defer gc.set.mu.RUnlock()
+	return gc.set.collectors
+}
+
+// WithContext returns a copy of gc whose D-Bus calls are bound to ctx
+func (gc GaneshaCollector) WithContext(ctx context.Context) GaneshaCollector {
+	gc.ctx = ctx
+	return gc
+}
+
+// Describe prometheus description
+func (gc GaneshaCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- upDesc
+	ch <- reconnectsDesc
+	ch <- scrapeDurationDesc
+	ch <- scrapeSuccessDesc
+	gc.timeouts.Describe(ch)
+	for _, c := range gc.Collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect checks ganesha is running, then runs every collector concurrently
+func (gc GaneshaCollector) Collect(ch chan<- prometheus.Metric) {
+	up, err := gc.conn.ServiceRunning(gc.ctx)
+	if err != nil {
+		log.Errorf("ganesha service lookup failed: %v", err)
+	}
+	ch <- prometheus.MustNewConstMetric(reconnectsDesc, prometheus.CounterValue, float64(gc.conn.Reconnects()))
+	collectors := gc.Collectors()
+	if !up {
+		ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 0)
+		// collectors are not run, their health series are kept so that
+		// they do not vanish exactly when ganesha is down
+		for name, c := range collectors {
+			ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, 0, name)
+			ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, 0, name)
+			c.CollectErrors(ch)
+		}
+		gc.timeouts.Collect(ch)
+		return
+	}
+	ch <- prometheus.MustNewConstMetric(upDesc, prometheus.GaugeValue, 1)
+
+	wg := sync.WaitGroup{}
+	wg.Add(len(collectors))
+	for name, c := range collectors {
+		go func(name string, c Collector) {
+			gc.execute(name, c, ch)
+			wg.Done()
+		}(name, c)
+	}
+	wg.Wait()
+	gc.timeouts.Collect(ch)
+}
+
+func (gc GaneshaCollector) execute(name string, c Collector, ch chan<- prometheus.Metric) {
+	begin := time.Now()
+	err := c.Update(gc.ctx, ch)
+	duration := time.Since(begin)
+	c.CollectErrors(ch)
+	var success float64
+
+	if gc.ctx.Err() != nil {
+		gc.timeouts.WithLabelValues(name).Inc()
+		if err == nil {
+			err = gc.ctx.Err()
+		}
+	}
+
+	if err != nil {
+		log.Errorf("%s collector failed after %fs: %v", name, duration.Seconds(), err)
+		success = 0
+	} else {
+		log.Debugf("%s collector succeeded after %fs", name, duration.Seconds())
+		success = 1
+	}
+	ch <- prometheus.MustNewConstMetric(scrapeDurationDesc, prometheus.GaugeValue, duration.Seconds(), name)
+	ch <- prometheus.MustNewConstMetric(scrapeSuccessDesc, prometheus.GaugeValue, success, name)
+}
+
+// newDBusErrorsCounter creates the counter of the failed D-Bus calls of the
+// named collector
+func newDBusErrorsCounter(collector string) prometheus.Counter {
+	return prometheus.NewCounter(prometheus.CounterOpts{
+		Name:        "ganesha_exporter_dbus_errors_total",
+		Help:        "Number of failed D-Bus calls to ganesha",
+		ConstLabels: prometheus.Labels{"collector": collector},
+	})
+}
+
+// countError logs and counts a failed call in errors, unless it failed
+// because the scrape context is done, which is counted as a timeout
+func countError(errors prometheus.Counter, err error, call string) {
+	if err == context.Canceled || err == context.DeadlineExceeded {
+		return
+	}
+	log.Errorf("%s: %v", call, err)
+	errors.Inc()
+}
+
+// parallel calls fn for every index below n from at most workers goroutines
+func parallel(workers, n int, fn func(i int)) {
+	if workers < 1 {
+		workers = 1
+	}
+	if workers > n {
+		workers = n
+	}
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+	wg.Add(workers)
+	for w := 0; w < workers; w++ {
+		go func() {
+			for i := range indexes {
+				fn(i)
+			}
+			wg.Done()
+		}()
+	}
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+	close(indexes)
+	wg.Wait()
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestParallel(t *testing.T) {
+	tests := []struct {
+		name    string
+		workers int
+		n       int
+	}{
+		{"no work", 4, 0},
+		{"fewer items than workers", 8, 3},
+		{"more items than workers", 3, 20},
+		{"no worker", 0, 5},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mu := sync.Mutex{}
+			calls := make([]int, tt.n)
+			running, maxRunning := 0, 0
+			parallel(tt.workers, tt.n, func(i int) {
+				mu.Lock()
+				calls[i]++
+				running++
+				if running > maxRunning {
+					maxRunning = running
+				}
+				mu.Unlock()
+				time.Sleep(time.Millisecond)
+				mu.Lock()
+				running--
+				mu.Unlock()
+			})
+			for i, n := range calls {
+				if n != 1 {
+					t.Errorf("index %d called %d times, want once", i, n)
+				}
+			}
+			limit := tt.workers
+			if limit < 1 {
+				limit = 1
+			}
+			if maxRunning > limit {
+				t.Errorf("%d concurrent calls, want at most %d", maxRunning, limit)
+			}
+		})
+	}
+}
+
+func TestActiveFilterEmit(t *testing.T) {
+	ok := dbus.StatsBaseAnswer{Status: true, Error: "OK"}
+	failed := dbus.StatsBaseAnswer{Error: "Export does not have any NFSv3 activity"}
+	tests := []struct {
+		name       string
+		activeOnly bool
+		served     bool
+		answer     dbus.StatsBaseAnswer
+		want       bool
+		wantErrors float64
+	}{
+		{"served", false, true, ok, true, 0},
+		{"not served", false, false, dbus.StatsBaseAnswer{}, true, 0},
+		{"served failed", false, true, failed, true, 1},
+		{"active only served", true, true, ok, true, 0},
+		{"active only not served", true, false, dbus.StatsBaseAnswer{}, false, 0},
+		{"active only served failed", true, true, failed, false, 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := newActiveFilter(tt.activeOnly, "exports")
+			if got := f.emit(tt.served, tt.answer); got != tt.want {
+				t.Errorf("emit(%v, %+v) = %v, want %v", tt.served, tt.answer, got, tt.want)
+			}
+			reg := prometheus.NewRegistry()
+			reg.MustRegister(f.statsErrors)
+			families, err := reg.Gather()
+			if err != nil {
+				t.Fatal(err)
+			}
+			var errors float64
+			for _, family := range families {
+				for _, m := range family.GetMetric() {
+					errors += m.GetCounter().GetValue()
+				}
+			}
+			if errors != tt.wantErrors {
+				t.Errorf("emit(%v, %+v) counted %v errors, want %v", tt.served, tt.answer, errors, tt.wantErrors)
+			}
+		})
+	}
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+var (
+	ctlCmd     = kingpin.Command("ctl", "Manage ganesha through its D-Bus interface")
+	ctlOutput  = ctlCmd.Flag("output", "Output format, table or json").Short('o').Default("table").Enum("table", "json")
+	ctlTimeout = ctlCmd.Flag("timeout", "Timeout of the D-Bus calls").Default("10s").Duration()
+
+	ctlExportsCmd        = ctlCmd.Command("exports", "Manage exports")
+	ctlExportsListCmd    = ctlExportsCmd.Command("list", "List exports")
+	ctlExportsShowCmd    = ctlExportsCmd.Command("show", "Show an export")
+	ctlExportsShowID     = ctlExportsShowCmd.Arg("id", "Export ID").Required().Uint16()
+	ctlExportsAddCmd     = ctlExportsCmd.Command("add", "Add the exports of a configuration file")
+	ctlExportsAddFile    = ctlExportsAddCmd.Arg("file", "Configuration file, as seen by ganesha").Required().String()
+	ctlExportsAddExpr    = ctlExportsAddCmd.Arg("expr", "Exports to add from the file, e.g. EXPORT(Export_Id=2)").Required().String()
+	ctlExportsRemoveCmd  = ctlExportsCmd.Command("remove", "Remove an export")
+	ctlExportsRemoveID   = ctlExportsRemoveCmd.Arg("id", "Export ID").Required().Uint16()
+	ctlExportsUpdateCmd  = ctlExportsCmd.Command("update", "Update exports from a configuration file")
+	ctlExportsUpdateFile = ctlExportsUpdateCmd.Arg("file", "Configuration file, as seen by ganesha").Required().String()
+	ctlExportsUpdateExpr = ctlExportsUpdateCmd.Arg("expr", "Exports to update from the file, e.g. EXPORT(Export_Id=2)").Required().String()
+
+	ctlClientsCmd       = ctlCmd.Command("clients", "Manage clients")
+	ctlClientsListCmd   = ctlClientsCmd.Command("list", "List clients")
+	ctlClientsAddCmd    = ctlClientsCmd.Command("add", "Add a client to the ones ganesha tracks statistics for")
+	ctlClientsAddIP     = ctlClientsAddCmd.Arg("ip", "Client IP address").Required().String()
+	ctlClientsRemoveCmd = ctlClientsCmd.Command("remove", "Remove a client from the ones ganesha tracks statistics for")
+	ctlClientsRemoveIP  = ctlClientsRemoveCmd.Arg("ip", "Client IP address").Required().String()
+
+	ctlGraceCmd      = ctlCmd.Command("grace", "Manage the NFSv4 grace period")
+	ctlGraceStartCmd = ctlGraceCmd.Command("start", "Start a grace period")
+	ctlGraceStartIP  = ctlGraceStartCmd.Arg("ipaddr", "Address whose clients may reclaim their state, as [EVENT:]IP").Default("").String()
+
+	ctlReloadCmd = ctlCmd.Command("reload", "Reload the ganesha configuration")
+
+	ctlStatsCmd         = ctlCmd.Command("stats", "Manage statistics counting")
+	ctlStatsResetCmd    = ctlStatsCmd.Command("reset", "Reset every statistics counter")
+	ctlStatsEnableCmd   = ctlStatsCmd.Command("enable", "Enable counting statistics")
+	ctlStatsEnableType  = ctlStatsEnableCmd.Arg("type", "Statistics type, all or one of "+strings.Join(dbus.StatsTypes, ", ")).Required().Enum(append([]string{"all"}, dbus.StatsTypes...)...)
+	ctlStatsDisableCmd  = ctlStatsCmd.Command("disable", "Disable counting statistics")
+	ctlStatsDisableType = ctlStatsDisableCmd.Arg("type", "Statistics type, all or one of "+strings.Join(dbus.StatsTypes, ", ")).Required().Enum(append([]string{"all"}, dbus.StatsTypes...)...)
+)
+
+// protocolNames are the names of the protocols flagged on exports and
+// clients, in the order of their flags
+var protocolNames = []string{"nfsv3", "mntv3", "nlmv4", "rquota", "nfsv40", "nfsv41", "nfsv42", "9p"}
+
+// ctlExport is an export printed by ctl
+type ctlExport struct {
+	ExportID     uint32    `json:"export_id"`
+	Path         string    `json:"path"`
+	Protocols    []string  `json:"protocols"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// ctlExportDetails is the description of an export printed by ctl
+type ctlExportDetails struct {
+	ExportID uint16 `json:"export_id"`
+	Path     string `json:"path"`
+	Pseudo   string `json:"pseudo"`
+	Tag      string `json:"tag"`
+}
+
+// ctlClient is a client printed by ctl
+type ctlClient struct {
+	Client       string    `json:"client"`
+	Protocols    []string  `json:"protocols"`
+	LastActivity time.Time `json:"last_activity"`
+}
+
+// ctlMessage is the outcome of a ctl action
+type ctlMessage struct {
+	Message string `json:"message"`
+}
+
+// runCtl runs the ctl command against the ganesha behind conn and prints
+// its result
+func runCtl(command string, conn *dbus.Conn) error {
+	ctx, cancel := context.WithTimeout(context.Background(), *ctlTimeout)
+	defer cancel()
+	exportMgr := dbus.NewExportMgr(conn)
+	clientMgr := dbus.NewClientMgr(conn)
+	adminMgr := dbus.NewAdminMgr(conn)
+	statsMgr := dbus.NewStatsMgr(conn)
+
+	switch command {
+	case ctlExportsListCmd.FullCommand():
+		_, exports, err := exportMgr.ShowExports(ctx)
+		if err != nil {
+			return err
+		}
+		out := []ctlExport{}
+		rows := [][]string{}
+		for _, export := range exports {
+			e := ctlExport{
+				ExportID: export.ExportID,
+				Path:     export.Path,
+				Protocols: activeProtocols(export.NFSv3, export.MNTv3, export.NLMv4, export.RQUOTA,
+					export.NFSv40, export.NFSv41, export.NFSv42, export.Plan9),
+				LastActivity: time.Unix(export.LastTime.Unix()),
+			}
+			out = append(out, e)
+			rows = append(rows, []string{
+				strconv.FormatUint(uint64(e.ExportID), 10), e.Path,
+				strings.Join(e.Protocols, ","), e.LastActivity.Format(time.RFC3339),
+			})
+		}
+		return printCtl(out, []string{"EXPORT ID", "PATH", "PROTOCOLS", "LAST ACTIVITY"}, rows)
+	case ctlExportsShowCmd.FullCommand():
+		export, err := exportMgr.DisplayExport(ctx, *ctlExportsShowID)
+		if err != nil {
+			return err
+		}
+		out := ctlExportDetails(export)
+		return printCtl(out, []string{"EXPORT ID", "PATH", "PSEUDO", "TAG"}, [][]string{{
+			strconv.FormatUint(uint64(out.ExportID), 10), out.Path, out.Pseudo, out.Tag,
+		}})
+	case ctlExportsAddCmd.FullCommand():
+		message, err := exportMgr.AddExport(ctx, *ctlExportsAddFile, *ctlExportsAddExpr)
+		if err != nil {
+			return err
+		}
+		return printMessage(message)
+	case ctlExportsRemoveCmd.FullCommand():
+		if err := exportMgr.RemoveExport(ctx, *ctlExportsRemoveID); err != nil {
+			return err
+		}
+		return printMessage(fmt.Sprintf("export %d removed", *ctlExportsRemoveID))
+	case ctlExportsUpdateCmd.FullCommand():
+		message, err := exportMgr.UpdateExport(ctx, *ctlExportsUpdateFile, *ctlExportsUpdateExpr)
+		if err != nil {
+			return err
+		}
+		return printMessage(message)
+	case ctlClientsListCmd.FullCommand():
+		_, clients, err := clientMgr.ShowClients(ctx)
+		if err != nil {
+			return err
+		}
+		out := []ctlClient{}
+		rows := [][]string{}
+		for _, client := range clients {
+			c := ctlClient{
+				Client: client.Client,
+				Protocols: activeProtocols(client.NFSv3, client.MNTv3, client.NLMv4, client.RQUOTA,
+					client.NFSv40, client.NFSv41, client.NFSv42, client.Plan9),
+				LastActivity: time.Unix(client.LastTime.Unix()),
+			}
+			out = append(out, c)
+			rows = append(rows, []string{
+				c.Client, strings.Join(c.Protocols, ","), c.LastActivity.Format(time.RFC3339),
+			})
+		}
+		return printCtl(out, []string{"CLIENT", "PROTOCOLS", "LAST ACTIVITY"}, rows)
+	case ctlClientsAddCmd.FullCommand():
+		if err := clientMgr.AddClient(ctx, *ctlClientsAddIP); err != nil {
+			return err
+		}
+		return printMessage(fmt.Sprintf("client %s added", *ctlClientsAddIP))
+	case ctlClientsRemoveCmd.FullCommand():
+		if err := clientMgr.RemoveClient(ctx, *ctlClientsRemoveIP); err != nil {
+			return err
+		}
+		return printMessage(fmt.Sprintf("client %s removed", *ctlClientsRemoveIP))
+	case ctlGraceStartCmd.FullCommand():
+		if err := adminMgr.Grace(ctx, *ctlGraceStartIP); err != nil {
+			return err
+		}
+		return printMessage("grace period started")
+	case ctlReloadCmd.FullCommand():
+		if err := adminMgr.Reload(ctx); err != nil {
+			return err
+		}
+		return printMessage("configuration reloaded")
+	case ctlStatsResetCmd.FullCommand():
+		if err := statsMgr.ResetStats(ctx); err != nil {
+			return err
+		}
+		return printMessage("statistics reset")
+	case ctlStatsEnableCmd.FullCommand():
+		if err := statsMgr.EnableStats(ctx, *ctlStatsEnableType); err != nil {
+			return err
+		}
+		return printMessage(fmt.Sprintf("%s statistics enabled", *ctlStatsEnableType))
+	case ctlStatsDisableCmd.FullCommand():
+		if err := statsMgr.DisableStats(ctx, *ctlStatsDisableType); err != nil {
+			return err
+		}
+		return printMessage(fmt.Sprintf("%s statistics disabled", *ctlStatsDisableType))
+	}
+	return fmt.Errorf("unknown command %q", command)
+}
+
+// activeProtocols returns the names of the protocols whose flag is set,
+// flags are given in the order of protocolNames
+func activeProtocols(flags ...bool) []string {
+	protocols := []string{}
+	for i, active := range flags {
+		if active {
+			protocols = append(protocols, protocolNames[i])
+		}
+	}
+	return protocols
+}
+
+// printMessage prints the outcome of a ctl action
+func printMessage(message string) error {
+	return printCtl(ctlMessage{Message: message}, nil, [][]string{{message}})
+}
+
+// printCtl prints value as JSON, or header and rows as a table, depending
+// on the output flag
+func printCtl(value interface{}, header []string, rows [][]string) error {
+	if *ctlOutput == "json" {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		return enc.Encode(value)
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 8, 2, ' ', 0)
+	if header != nil {
+		fmt.Fprintln(w, strings.Join(header, "\t"))
+	}
+	for _, row := range rows {
+		fmt.Fprintln(w, strings.Join(row, "\t"))
+	}
+	return w.Flush()
+}
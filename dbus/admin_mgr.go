@@ -0,0 +1,93 @@
+package dbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/godbus/dbus"
+)
+
+const adminPath = "/org/ganesha/nfsd/admin"
+
+// Version stores the version properties of ganesha
+type Version struct {
+	Release     string
+	CompileDate string
+	CompileTime string
+	Comment     string
+	GitHead     string
+	GitDescribe string
+}
+
+// AdminMgr is a handle to dbus object admin
+type AdminMgr struct {
+	conn *Conn
+}
+
+// NewAdminMgr Get a new AdminMgr using conn
+func NewAdminMgr(conn *Conn) AdminMgr {
+	return AdminMgr{conn: conn}
+}
+
+// Version returns the version properties of the running ganesha
+func (mgr AdminMgr) Version(ctx context.Context) (Version, error) {
+	out := Version{}
+	props := map[string]dbus.Variant{}
+	err := mgr.conn.call(ctx, adminPath, "org.freedesktop.DBus.Properties.GetAll", "org.ganesha.nfsd.admin").
+		Store(&props)
+	if err != nil {
+		return out, err
+	}
+	out.Release = stringProp(props, "VERSION_RELEASE")
+	out.CompileDate = stringProp(props, "VERSION_COMPILE_DATE")
+	out.CompileTime = stringProp(props, "VERSION_COMPILE_TIME")
+	out.Comment = stringProp(props, "VERSION_COMMENT")
+	out.GitHead = stringProp(props, "VERSION_GIT_HEAD")
+	out.GitDescribe = stringProp(props, "VERSION_GIT_DESCRIBE")
+	return out, nil
+}
+
+// InGrace returns whether ganesha is in its NFSv4 grace period, during
+// which clients may only reclaim their state. Older ganesha versions do
+// not implement it
+func (mgr AdminMgr) InGrace(ctx context.Context) (bool, error) {
+	var inGrace bool
+	err := mgr.conn.call(ctx, adminPath, "org.ganesha.nfsd.admin.get_grace").Store(&inGrace)
+	return inGrace, err
+}
+
+// Grace starts a new grace period. ipaddr is given as [EVENT:]IP, where
+// IP is the address whose clients may reclaim their state
+func (mgr AdminMgr) Grace(ctx context.Context, ipaddr string) error {
+	call := mgr.conn.call(ctx, adminPath, "org.ganesha.nfsd.admin.grace", ipaddr)
+	return callSuccess(call)
+}
+
+// Reload makes ganesha reload its configuration
+func (mgr AdminMgr) Reload(ctx context.Context) error {
+	call := mgr.conn.call(ctx, adminPath, "org.ganesha.nfsd.admin.reload")
+	return callSuccess(call)
+}
+
+// callSuccess decodes the answer of management methods, made of a success
+// flag followed by an error message
+func callSuccess(call *dbus.Call) error {
+	if call.Err != nil {
+		return call.Err
+	}
+	var success bool
+	var errorMsg string
+	if err := call.Store(&success, &errorMsg); err != nil {
+		return err
+	}
+	if !success {
+		return fmt.Errorf("%s: %s", call.Method, errorMsg)
+	}
+	return nil
+}
+
+// stringProp returns the string property name, or an empty string when it
+// is missing
+func stringProp(props map[string]dbus.Variant, name string) string {
+	value, _ := props[name].Value().(string)
+	return value
+}
@@ -1,11 +1,12 @@
 package dbus
 
 import (
-	"github.com/godbus/dbus"
+	"context"
 	"golang.org/x/sys/unix"
-	"log"
 )
 
+const clientMgrPath = "/org/ganesha/nfsd/ClientMgr"
+
 // Client Structure of the output of ShowClients dbus call
 type Client struct {
 	Client   string
@@ -22,125 +23,165 @@ type Client struct {
 
 // ClientMgr is a handle to dbus object ClientMgr
 type ClientMgr struct {
-	dbusObject dbus.BusObject
+	conn *Conn
 }
 
-// NewClientMgr Get a new ClientMgr
-func NewClientMgr() ClientMgr {
-	conn, err := dbus.SystemBus()
-	if err != nil {
-		log.Panic(err)
-	}
-	return ClientMgr{
-		dbusObject: conn.Object(
-			"org.ganesha.nfsd",
-			"/org/ganesha/nfsd/ClientMgr",
-		),
-	}
+// NewClientMgr Get a new ClientMgr using conn
+func NewClientMgr(conn *Conn) ClientMgr {
+	return ClientMgr{conn: conn}
 }
 
-func (mgr ClientMgr) ShowClients() (unix.Timespec, []Client) {
+// ShowClients returns the time of the answer and the list of clients
+func (mgr ClientMgr) ShowClients(ctx context.Context) (unix.Timespec, []Client, error) {
 	var clients []Client
 	utime := unix.Timespec{}
-	err := mgr.dbusObject.
-		Call("org.ganesha.nfsd.clientmgr.ShowClients", 0).
+	err := mgr.conn.
+		call(ctx, clientMgrPath, "org.ganesha.nfsd.clientmgr.ShowClients").
 		Store(&utime, &clients)
-	if err != nil {
-		log.Panic(err)
-	}
-	return utime, clients
+	return utime, clients, err
+}
+
+// AddClient adds a client to the list ganesha tracks statistics for
+func (mgr ClientMgr) AddClient(ctx context.Context, ipaddr string) error {
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientmgr.AddClient", ipaddr)
+	return callSuccess(call)
+}
+
+// RemoveClient removes a client from the list ganesha tracks statistics for
+func (mgr ClientMgr) RemoveClient(ctx context.Context, ipaddr string) error {
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientmgr.RemoveClient", ipaddr)
+	return callSuccess(call)
 }
 
-func (mgr ClientMgr) GetNFSv3IO(ipaddr string) BasicStats {
+// GetNFSv3IO returns the NFSv3 IO statistics of a client
+func (mgr ClientMgr) GetNFSv3IO(ctx context.Context, ipaddr string) (BasicStats, error) {
 	out := BasicStats{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.clientstats.GetNFSv3IO", 0, ipaddr)
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientstats.GetNFSv3IO", ipaddr)
 	if call.Err != nil {
-		log.Panic(call.Err)
+		return out, call.Err
 	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
 	}
-	if err := call.Store(
+	err = call.Store(
 		&out.Status, &out.Error, &out.Time,
 		&out.Read, &out.Write,
-	); err != nil {
-		log.Panic(err)
-	}
-	return out
+	)
+	return out, err
 }
 
-func (mgr ClientMgr) GetNFSv40IO(ipaddr string) BasicStats {
+// GetNFSv40IO returns the NFSv4.0 IO statistics of a client
+func (mgr ClientMgr) GetNFSv40IO(ctx context.Context, ipaddr string) (BasicStats, error) {
 	out := BasicStats{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.clientstats.GetNFSv40IO", 0, ipaddr)
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientstats.GetNFSv40IO", ipaddr)
 	if call.Err != nil {
-		log.Panic(call.Err)
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
 	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
 	}
-	if err := call.Store(
+	err = call.Store(
 		&out.Status, &out.Error, &out.Time,
 		&out.Read, &out.Write,
-	); err != nil {
-		log.Panic(err)
+	)
+	return out, err
+}
+
+// GetNFSv41IO returns the NFSv4.1 IO statistics of a client
+func (mgr ClientMgr) GetNFSv41IO(ctx context.Context, ipaddr string) (BasicStats, error) {
+	out := BasicStats{}
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientstats.GetNFSv41IO", ipaddr)
+	if call.Err != nil {
+		return out, call.Err
+	}
+	err := storeBasicStats(call, &out)
+	return out, err
+}
+
+// GetNFSv41Layouts returns the pNFSv4.1 layouts statistics of a client
+func (mgr ClientMgr) GetNFSv41Layouts(ctx context.Context, ipaddr string) (PNFSOperations, error) {
+	out := PNFSOperations{}
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientstats.GetNFSv41Layouts", ipaddr)
+	if call.Err != nil {
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
 	}
-	return out
+	err = call.Store(
+		&out.Status, &out.Error, &out.Time,
+		&out.Getdevinfo, &out.LayoutGet, &out.LayoutCommit, &out.LayoutReturn, &out.LayoutRecall,
+	)
+	return out, err
 }
 
-func (mgr ClientMgr) GetNFSv41IO(ipaddr string) BasicStats {
+// GetNFSv42IO returns the NFSv4.2 IO statistics of a client
+func (mgr ClientMgr) GetNFSv42IO(ctx context.Context, ipaddr string) (BasicStats, error) {
 	out := BasicStats{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.clientstats.GetNFSv41IO", 0, ipaddr)
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientstats.GetNFSv42IO", ipaddr)
 	if call.Err != nil {
-		log.Panic(call.Err)
-	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
-	}
-	if Gandi {
-		if err := call.Store(
-			&out.Status, &out.Error, &out.Time,
-			&out.Read, &out.Write,
-			&out.Open, &out.Close, &out.Getattr, &out.Lock,
-		); err != nil {
-			log.Panic(err)
-		}
-	} else {
-		if err := call.Store(
-			&out.Status, &out.Error, &out.Time,
-			&out.Read, &out.Write,
-		); err != nil {
-			log.Panic(err)
-		}
-	}
-	return out
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
+	}
+	err = call.Store(
+		&out.Status, &out.Error, &out.Time,
+		&out.Read, &out.Write,
+	)
+	return out, err
 }
 
-func (mgr ClientMgr) GetNFSv41Layouts(ipaddr string) PNFSOperations {
+// GetNFSv42Layouts returns the pNFSv4.2 layouts statistics of a client
+func (mgr ClientMgr) GetNFSv42Layouts(ctx context.Context, ipaddr string) (PNFSOperations, error) {
 	out := PNFSOperations{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.clientstats.GetNFSv41Layouts", 0, ipaddr)
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientstats.GetNFSv42Layouts", ipaddr)
 	if call.Err != nil {
-		log.Panic(call.Err)
+		return out, call.Err
 	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
 	}
-	if err := call.Store(
+	err = call.Store(
 		&out.Status, &out.Error, &out.Time,
 		&out.Getdevinfo, &out.LayoutGet, &out.LayoutCommit, &out.LayoutReturn, &out.LayoutRecall,
-	); err != nil {
-		log.Panic(err)
+	)
+	return out, err
+}
+
+// GetClientAllops returns the statistics of every operation of a client,
+// for the NFSv3, NFSv4, NLMv4, MNT and RQUOTA protocols
+func (mgr ClientMgr) GetClientAllops(ctx context.Context, ipaddr string) (AllOperations, error) {
+	out := AllOperations{}
+	call := mgr.conn.call(ctx, clientMgrPath, "org.ganesha.nfsd.clientstats.GetClientAllops", ipaddr)
+	if call.Err != nil {
+		return out, call.Err
 	}
-	return out
+	err := storeAllOps(call, &out)
+	return out, err
 }
@@ -0,0 +1,415 @@
+package dbus
+
+import (
+	"context"
+	"fmt"
+	"github.com/godbus/dbus"
+	"net"
+	"os"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	minBackoff = time.Second
+	maxBackoff = time.Minute
+	// dialTimeout bounds a connection attempt. The attempt is shared by
+	// every caller waiting for it, it is not bound to any of their contexts
+	dialTimeout = 30 * time.Second
+)
+
+const defaultSystemBusAddress = "unix:path=/var/run/dbus/system_bus_socket"
+
+var errClosed = fmt.Errorf("connection closed")
+
+// Conn is a connection to the bus ganesha is registered on. It is dialed
+// on first use, and dialed again, with an exponential backoff between
+// failed attempts, once it is closed or ganesha loses its name on the bus.
+type Conn struct {
+	bus        string
+	address    string
+	service    string
+	mu         sync.Mutex
+	conn       *dbus.Conn
+	lost       bool
+	lastErr    error
+	backoff    time.Duration
+	retryAt    time.Time
+	reconnects uint64
+	onConnect  []func()
+	name       string
+	dialing    chan struct{}
+	cancelDial context.CancelFunc
+	closed     bool
+}
+
+// NewConn Get a new Conn to the ganesha owning service on the bus at
+// address, or on the well-known bus named bus ("system" or "session") when
+// address is empty. No connection is made until it is used
+func NewConn(bus, address, service string) *Conn {
+	return &Conn{
+		bus:     bus,
+		address: address,
+		service: service,
+	}
+}
+
+// OnConnect registers fn to be called, from its own goroutine, every time
+// the connection is established and every time ganesha appears on the bus
+func (c *Conn) OnConnect(fn func()) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.onConnect = append(c.onConnect, fn)
+}
+
+// Connect starts establishing the connection now rather than on first use,
+// without waiting for it
+func (c *Conn) Connect() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.conn == nil && c.dialing == nil && !c.closed && !time.Now().Before(c.retryAt) {
+		c.startDial()
+	}
+}
+
+// Reconnects returns how many times the connection has been re-established
+func (c *Conn) Reconnects() uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.reconnects
+}
+
+// Close closes the current connection, if any, and abandons the one being
+// dialed. The Conn can not be used afterwards
+func (c *Conn) Close() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.closed = true
+	if c.cancelDial != nil {
+		c.cancelDial()
+	}
+	if c.conn == nil {
+		return nil
+	}
+	err := c.conn.Close()
+	c.conn = nil
+	return err
+}
+
+// ServiceRunning reports whether ganesha currently owns its bus name
+func (c *Conn) ServiceRunning(ctx context.Context) (bool, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	var hasOwner bool
+	err = callWithContext(ctx, conn.BusObject(), "org.freedesktop.DBus.NameHasOwner", c.service).
+		Store(&hasOwner)
+	return hasOwner, err
+}
+
+// ServicePID returns the process ID of ganesha. It fails when the bus
+// does not report process IDs in our PID namespace, where the ID would not
+// designate ganesha
+func (c *Conn) ServicePID(ctx context.Context) (uint32, error) {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	name := c.name
+	c.mu.Unlock()
+	var self, pid uint32
+	err = callWithContext(ctx, conn.BusObject(), "org.freedesktop.DBus.GetConnectionUnixProcessID", name).
+		Store(&self)
+	if err != nil {
+		return 0, err
+	}
+	if int(self) != os.Getpid() {
+		return 0, fmt.Errorf("the bus reports process IDs of another PID namespace")
+	}
+	err = callWithContext(ctx, conn.BusObject(), "org.freedesktop.DBus.GetConnectionUnixProcessID", c.service).
+		Store(&pid)
+	return pid, err
+}
+
+// call calls method on the ganesha object at path, connection errors
+// are reported in the Err field of the returned call
+func (c *Conn) call(ctx context.Context, path dbus.ObjectPath, method string, args ...interface{}) *dbus.Call {
+	conn, err := c.get(ctx)
+	if err != nil {
+		return &dbus.Call{Method: method, Err: err}
+	}
+	return callWithContext(ctx, conn.Object(c.service, path), method, args...)
+}
+
+// callWithContext calls method on obj, giving up when ctx is done. Our
+// godbus version has no CallWithContext, the reply of an abandoned call is
+// dropped when it arrives
+func callWithContext(ctx context.Context, obj dbus.BusObject, method string, args ...interface{}) *dbus.Call {
+	if err := ctx.Err(); err != nil {
+		return &dbus.Call{Method: method, Err: err}
+	}
+	call := obj.Go(method, 0, make(chan *dbus.Call, 1), args...)
+	select {
+	case call = <-call.Done:
+		return call
+	case <-ctx.Done():
+		return &dbus.Call{Method: method, Err: ctx.Err()}
+	}
+}
+
+// IsUnknownMethod reports whether err is ganesha refusing a method it
+// does not implement
+func IsUnknownMethod(err error) bool {
+	dbusErr, ok := err.(dbus.Error)
+	return ok && dbusErr.Name == "org.freedesktop.DBus.Error.UnknownMethod"
+}
+
+// get returns the current connection, waiting for it to be dialed if
+// needed, or until ctx is done
+func (c *Conn) get(ctx context.Context) (*dbus.Conn, error) {
+	for {
+		c.mu.Lock()
+		if c.conn != nil {
+			conn := c.conn
+			c.mu.Unlock()
+			return conn, nil
+		}
+		if c.closed {
+			c.mu.Unlock()
+			return nil, errClosed
+		}
+		if c.dialing == nil {
+			if time.Now().Before(c.retryAt) {
+				err := c.lastErr
+				c.mu.Unlock()
+				return nil, err
+			}
+			c.startDial()
+		}
+		dialing := c.dialing
+		c.mu.Unlock()
+
+		select {
+		case <-dialing:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// startDial dials a new connection in the background, c.dialing is closed
+// once it is done. c.mu must be held
+func (c *Conn) startDial() {
+	ctx, cancel := context.WithTimeout(context.Background(), dialTimeout)
+	dialing := make(chan struct{})
+	c.dialing = dialing
+	c.cancelDial = cancel
+	go func() {
+		conn, name, signals, err := dial(ctx, c.bus, c.address, c.service)
+		cancel()
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		defer close(dialing)
+		c.dialing = nil
+		c.cancelDial = nil
+		switch {
+		case err != nil:
+			c.backoff *= 2
+			if c.backoff < minBackoff {
+				c.backoff = minBackoff
+			}
+			if c.backoff > maxBackoff {
+				c.backoff = maxBackoff
+			}
+			c.retryAt = time.Now().Add(c.backoff)
+			c.lastErr = err
+		case c.closed:
+			conn.Close()
+		default:
+			if c.lost {
+				c.reconnects++
+				c.lost = false
+			}
+			c.conn = conn
+			c.name = name
+			c.backoff = 0
+			c.lastErr = nil
+			go c.watch(conn, signals)
+			c.connected()
+		}
+	}()
+}
+
+// connected calls the OnConnect functions. c.mu must be held
+func (c *Conn) connected() {
+	for _, fn := range c.onConnect {
+		go fn()
+	}
+}
+
+// watch waits for the connection to be closed or for ganesha to lose its
+// name, after which the connection is dropped so the next call redials.
+// Ganesha appearing on the bus only calls the OnConnect functions, as no
+// connection to it was lost
+func (c *Conn) watch(conn *dbus.Conn, signals chan *dbus.Signal) {
+	for signal := range signals {
+		if signal.Name != "org.freedesktop.DBus.NameOwnerChanged" ||
+			len(signal.Body) < 3 || signal.Body[0] != c.service {
+			continue
+		}
+		if oldOwner, _ := signal.Body[1].(string); oldOwner != "" {
+			conn.Close()
+			continue
+		}
+		c.mu.Lock()
+		if c.conn == conn {
+			c.connected()
+		}
+		c.mu.Unlock()
+	}
+	c.mu.Lock()
+	if c.conn == conn {
+		c.conn = nil
+		c.lost = true
+	}
+	c.mu.Unlock()
+}
+
+// dial opens a private connection to the bus and subscribes to ganesha
+// owner changes, the signals channel is closed along with the connection.
+// It returns the unique name of the connection on the bus
+func dial(ctx context.Context, bus, address, service string) (*dbus.Conn, string, chan *dbus.Signal, error) {
+	address, err := busAddress(bus, address)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	sock, err := dialAddress(ctx, address)
+	if err != nil {
+		return nil, "", nil, err
+	}
+	conn, err := dbus.NewConn(sock)
+	if err != nil {
+		sock.Close()
+		return nil, "", nil, err
+	}
+	// the handshake has no context support, it is bounded by the deadline
+	// of the socket instead
+	deadline, _ := ctx.Deadline()
+	if err = sock.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return nil, "", nil, err
+	}
+	if err = conn.Auth(nil); err != nil {
+		conn.Close()
+		return nil, "", nil, err
+	}
+	if err = sock.SetDeadline(time.Time{}); err != nil {
+		conn.Close()
+		return nil, "", nil, err
+	}
+	// Hello is called by hand to give up with ctx, godbus then does not
+	// know the name of the connection and accepts every message
+	var name string
+	err = callWithContext(ctx, conn.BusObject(), "org.freedesktop.DBus.Hello").Store(&name)
+	if err != nil {
+		conn.Close()
+		return nil, "", nil, err
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	err = callWithContext(ctx, conn.BusObject(),
+		"org.freedesktop.DBus.AddMatch",
+		"type='signal',sender='org.freedesktop.DBus',interface='org.freedesktop.DBus',"+
+			"member='NameOwnerChanged',arg0='"+service+"'",
+	).Err
+	if err != nil {
+		conn.Close()
+		return nil, "", nil, err
+	}
+	return conn, name, signals, nil
+}
+
+// busAddress returns the address of the bus to dial, address itself or the
+// address of the well-known bus named bus when it is empty
+func busAddress(bus, address string) (string, error) {
+	switch {
+	case address != "":
+		return address, nil
+	case bus == "system":
+		if address := os.Getenv("DBUS_SYSTEM_BUS_ADDRESS"); address != "" {
+			return address, nil
+		}
+		return defaultSystemBusAddress, nil
+	case bus == "session":
+		if address := os.Getenv("DBUS_SESSION_BUS_ADDRESS"); address != "" {
+			return address, nil
+		}
+		return "", fmt.Errorf("no session bus, DBUS_SESSION_BUS_ADDRESS is not set")
+	}
+	return "", fmt.Errorf("unknown bus %q", bus)
+}
+
+// dialAddress dials the first reachable of the semicolon separated
+// addresses of a bus, supporting the unix and tcp transports
+func dialAddress(ctx context.Context, address string) (net.Conn, error) {
+	var dialer net.Dialer
+	err := fmt.Errorf("empty bus address")
+	for _, addr := range strings.Split(address, ";") {
+		if addr == "" {
+			continue
+		}
+		var network, target string
+		network, target, err = parseBusAddress(addr)
+		if err != nil {
+			continue
+		}
+		var conn net.Conn
+		conn, err = dialer.DialContext(ctx, network, target)
+		if err == nil {
+			return conn, nil
+		}
+	}
+	return nil, err
+}
+
+// parseBusAddress returns the network and address to dial for a single bus
+// address such as unix:path=/run/dbus/system_bus_socket or
+// tcp:host=localhost,port=12345
+func parseBusAddress(address string) (string, string, error) {
+	i := strings.Index(address, ":")
+	if i < 0 {
+		return "", "", fmt.Errorf("invalid bus address %q", address)
+	}
+	keys := map[string]string{}
+	for _, kv := range strings.Split(address[i+1:], ",") {
+		if j := strings.Index(kv, "="); j >= 0 {
+			keys[kv[:j]] = kv[j+1:]
+		}
+	}
+	switch address[:i] {
+	case "unix":
+		if path := keys["path"]; path != "" {
+			return "unix", path, nil
+		}
+		if abstract := keys["abstract"]; abstract != "" {
+			return "unix", "@" + abstract, nil
+		}
+	case "tcp":
+		network := "tcp"
+		switch keys["family"] {
+		case "ipv4":
+			network = "tcp4"
+		case "ipv6":
+			network = "tcp6"
+		}
+		if keys["host"] != "" && keys["port"] != "" {
+			return network, net.JoinHostPort(keys["host"], keys["port"]), nil
+		}
+	default:
+		return "", "", fmt.Errorf("unsupported transport in bus address %q", address)
+	}
+	return "", "", fmt.Errorf("invalid bus address %q", address)
+}
@@ -0,0 +1,87 @@
+package dbus
+
+import (
+	"os"
+	"testing"
+)
+
+func TestParseBusAddress(t *testing.T) {
+	tests := []struct {
+		name        string
+		address     string
+		wantNetwork string
+		wantTarget  string
+		wantErr     bool
+	}{
+		{"unix path", "unix:path=/run/dbus/system_bus_socket", "unix", "/run/dbus/system_bus_socket", false},
+		{"unix path with guid", "unix:path=/run/ganesha/bus,guid=0123456789abcdef", "unix", "/run/ganesha/bus", false},
+		{"unix abstract", "unix:abstract=/tmp/dbus-XXXX", "unix", "@/tmp/dbus-XXXX", false},
+		{"tcp", "tcp:host=10.0.0.1,port=12345", "tcp", "10.0.0.1:12345", false},
+		{"tcp ipv4", "tcp:host=localhost,port=12345,family=ipv4", "tcp4", "localhost:12345", false},
+		{"tcp ipv6", "tcp:host=::1,port=12345,family=ipv6", "tcp6", "[::1]:12345", false},
+		{"tcp without port", "tcp:host=10.0.0.1", "", "", true},
+		{"unix without path", "unix:guid=0123456789abcdef", "", "", true},
+		{"unsupported transport", "launchd:env=DBUS_LAUNCHD_SESSION_BUS_SOCKET", "", "", true},
+		{"no transport", "/run/dbus/system_bus_socket", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			network, target, err := parseBusAddress(tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseBusAddress(%q) error = %v, wantErr %v", tt.address, err, tt.wantErr)
+			}
+			if network != tt.wantNetwork || target != tt.wantTarget {
+				t.Errorf("parseBusAddress(%q) = %q, %q, want %q, %q", tt.address, network, target, tt.wantNetwork, tt.wantTarget)
+			}
+		})
+	}
+}
+
+func TestBusAddress(t *testing.T) {
+	for _, name := range []string{"DBUS_SYSTEM_BUS_ADDRESS", "DBUS_SESSION_BUS_ADDRESS"} {
+		if value, ok := os.LookupEnv(name); ok {
+			defer os.Setenv(name, value)
+		} else {
+			defer os.Unsetenv(name)
+		}
+	}
+	tests := []struct {
+		name    string
+		env     map[string]string
+		bus     string
+		address string
+		want    string
+		wantErr bool
+	}{
+		{"address", nil, "system", "unix:path=/run/ganesha/bus", "unix:path=/run/ganesha/bus", false},
+		{"default system bus", nil, "system", "", defaultSystemBusAddress, false},
+		{
+			"system bus from env",
+			map[string]string{"DBUS_SYSTEM_BUS_ADDRESS": "unix:path=/run/system"},
+			"system", "", "unix:path=/run/system", false,
+		},
+		{
+			"session bus from env",
+			map[string]string{"DBUS_SESSION_BUS_ADDRESS": "unix:abstract=session"},
+			"session", "", "unix:abstract=session", false,
+		},
+		{"no session bus", nil, "session", "", "", true},
+		{"unknown bus", nil, "starter", "", "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Unsetenv("DBUS_SYSTEM_BUS_ADDRESS")
+			os.Unsetenv("DBUS_SESSION_BUS_ADDRESS")
+			for name, value := range tt.env {
+				os.Setenv(name, value)
+			}
+			got, err := busAddress(tt.bus, tt.address)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("busAddress(%q, %q) error = %v, wantErr %v", tt.bus, tt.address, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("busAddress(%q, %q) = %q, want %q", tt.bus, tt.address, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,6 +3,6 @@ Package dbus act as a simple low-level wrapper for NFS Ganesha dbus calls. It
 follows the documentation of NFS Ganesha available here:
 https://github.com/nfs-ganesha/nfs-ganesha/wiki/Dbusinterface
 
-Please note that for now, some of the defined structures contains elements specific to Gandi version of Ganesha, the fields are marked as such.
+Please note that for now, some of the defined structures contains elements specific to Gandi version of Ganesha, the fields are marked as such and only filled when ganesha provides them.
 */
 package dbus
@@ -1,11 +1,14 @@
 package dbus
 
 import (
+	"context"
+	"fmt"
 	"github.com/godbus/dbus"
 	"golang.org/x/sys/unix"
-	"log"
 )
 
+const exportMgrPath = "/org/ganesha/nfsd/ExportMgr"
+
 // Export Structure of the output of ShowExports dbus call
 type Export struct {
 	ExportID uint32
@@ -21,127 +24,276 @@ type Export struct {
 	LastTime unix.Timespec
 }
 
+// ExportDetails stores the description of an export
+type ExportDetails struct {
+	ExportID uint16
+	Path     string
+	Pseudo   string
+	Tag      string
+}
+
 // ExportMgr is a handle to dbus object ExportMgr
 type ExportMgr struct {
-	dbusObject dbus.BusObject
+	conn *Conn
 }
 
-// NewExportMgr Get a new ExportMgr
-func NewExportMgr() ExportMgr {
-	conn, err := dbus.SystemBus()
-	if err != nil {
-		log.Panic(err)
-	}
-	return ExportMgr{
-		dbusObject: conn.Object(
-			"org.ganesha.nfsd",
-			"/org/ganesha/nfsd/ExportMgr",
-		),
-	}
+// NewExportMgr Get a new ExportMgr using conn
+func NewExportMgr(conn *Conn) ExportMgr {
+	return ExportMgr{conn: conn}
 }
 
-func (mgr ExportMgr) ShowExports() (unix.Timespec, []Export) {
+// ShowExports returns the time of the answer and the list of exports
+func (mgr ExportMgr) ShowExports(ctx context.Context) (unix.Timespec, []Export, error) {
 	var exports []Export
 	utime := unix.Timespec{}
-	err := mgr.dbusObject.
-		Call("org.ganesha.nfsd.exportmgr.ShowExports", 0).
+	err := mgr.conn.
+		call(ctx, exportMgrPath, "org.ganesha.nfsd.exportmgr.ShowExports").
 		Store(&utime, &exports)
-	if err != nil {
-		log.Panic(err)
+	return utime, exports, err
+}
+
+// DisplayExport returns the description of an export
+func (mgr ExportMgr) DisplayExport(ctx context.Context, exportID uint16) (ExportDetails, error) {
+	out := ExportDetails{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportmgr.DisplayExport", exportID)
+	if call.Err != nil {
+		return out, call.Err
 	}
-	return utime, exports
+	// the clients of the export follow, in a format that changed across
+	// ganesha versions
+	if len(call.Body) < 4 {
+		return out, fmt.Errorf("%s: unexpected reply signature", call.Method)
+	}
+	err := dbus.Store(call.Body[:4], &out.ExportID, &out.Path, &out.Pseudo, &out.Tag)
+	return out, err
+}
+
+// AddExport adds the exports of the configuration file path matching
+// expr, such as EXPORT(Export_Id=2), it returns the message of ganesha
+func (mgr ExportMgr) AddExport(ctx context.Context, path, expr string) (string, error) {
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportmgr.AddExport", path, expr)
+	return callMessage(call)
+}
+
+// UpdateExport updates the exports of the configuration file path matching
+// expr, it returns the message of ganesha
+func (mgr ExportMgr) UpdateExport(ctx context.Context, path, expr string) (string, error) {
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportmgr.UpdateExport", path, expr)
+	return callMessage(call)
 }
 
-func (mgr ExportMgr) GetNFSv3IO(exportID uint32) BasicStats {
+// RemoveExport removes an export
+func (mgr ExportMgr) RemoveExport(ctx context.Context, exportID uint16) error {
+	return mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportmgr.RemoveExport", exportID).Err
+}
+
+// callMessage returns the message answered by ganesha, if any
+func callMessage(call *dbus.Call) (string, error) {
+	if call.Err != nil {
+		return "", call.Err
+	}
+	if len(call.Body) == 0 {
+		return "", nil
+	}
+	message, _ := call.Body[0].(string)
+	return message, nil
+}
+
+// GetNFSv3IO returns the NFSv3 IO statistics of an export
+func (mgr ExportMgr) GetNFSv3IO(ctx context.Context, exportID uint32) (BasicStats, error) {
 	out := BasicStats{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.exportstats.GetNFSv3IO", 0, exportID)
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetNFSv3IO", exportID)
 	if call.Err != nil {
-		log.Panic(call.Err)
+		return out, call.Err
 	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
 	}
-	if err := call.Store(
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
+	}
+	err = call.Store(
 		&out.Status, &out.Error, &out.Time,
 		&out.Read, &out.Write,
-	); err != nil {
-		log.Panic(err)
-	}
-	return out
+	)
+	return out, err
 }
 
-func (mgr ExportMgr) GetNFSv40IO(exportID uint32) BasicStats {
+// GetNFSv40IO returns the NFSv4.0 IO statistics of an export
+func (mgr ExportMgr) GetNFSv40IO(ctx context.Context, exportID uint32) (BasicStats, error) {
 	out := BasicStats{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.exportstats.GetNFSv40IO", 0, exportID)
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetNFSv40IO", exportID)
 	if call.Err != nil {
-		log.Panic(call.Err)
+		return out, call.Err
 	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
 	}
-	if err := call.Store(
+	err = call.Store(
 		&out.Status, &out.Error, &out.Time,
 		&out.Read, &out.Write,
-	); err != nil {
-		log.Panic(err)
+	)
+	return out, err
+}
+
+// GetNFSv41IO returns the NFSv4.1 IO statistics of an export
+func (mgr ExportMgr) GetNFSv41IO(ctx context.Context, exportID uint32) (BasicStats, error) {
+	out := BasicStats{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetNFSv41IO", exportID)
+	if call.Err != nil {
+		return out, call.Err
 	}
-	return out
+	err := storeBasicStats(call, &out)
+	return out, err
 }
 
-func (mgr ExportMgr) GetNFSv41IO(exportID uint32) BasicStats {
+// GetNFSv41Layouts returns the pNFSv4.1 layouts statistics of an export
+func (mgr ExportMgr) GetNFSv41Layouts(ctx context.Context, exportID uint32) (PNFSOperations, error) {
+	out := PNFSOperations{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetNFSv41Layouts", exportID)
+	if call.Err != nil {
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
+	}
+	err = call.Store(
+		&out.Status, &out.Error, &out.Time,
+		&out.Getdevinfo, &out.LayoutGet, &out.LayoutCommit, &out.LayoutReturn, &out.LayoutRecall,
+	)
+	return out, err
+}
+
+// GetNFSv42IO returns the NFSv4.2 IO statistics of an export
+func (mgr ExportMgr) GetNFSv42IO(ctx context.Context, exportID uint32) (BasicStats, error) {
 	out := BasicStats{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.exportstats.GetNFSv41IO", 0, exportID)
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetNFSv42IO", exportID)
 	if call.Err != nil {
-		log.Panic(call.Err)
-	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
-	}
-	if Gandi {
-		if err := call.Store(
-			&out.Status, &out.Error, &out.Time,
-			&out.Read, &out.Write,
-			&out.Open, &out.Close, &out.Getattr, &out.Lock,
-		); err != nil {
-			log.Panic(err)
-		}
-	} else {
-		if err := call.Store(
-			&out.Status, &out.Error, &out.Time,
-			&out.Read, &out.Write,
-		); err != nil {
-			log.Panic(err)
-		}
-	}
-	return out
-}
-
-func (mgr ExportMgr) GetNFSv41Layouts(exportID uint32) PNFSOperations {
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
+	}
+	err = call.Store(
+		&out.Status, &out.Error, &out.Time,
+		&out.Read, &out.Write,
+	)
+	return out, err
+}
+
+// GetNFSv42Layouts returns the pNFSv4.2 layouts statistics of an export
+func (mgr ExportMgr) GetNFSv42Layouts(ctx context.Context, exportID uint32) (PNFSOperations, error) {
 	out := PNFSOperations{}
-	call := mgr.dbusObject.Call("org.ganesha.nfsd.exportstats.GetNFSv41Layouts", 0, exportID)
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetNFSv42Layouts", exportID)
 	if call.Err != nil {
-		log.Panic(call.Err)
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
 	}
-	if !call.Body[0].(bool) {
-		if err := call.Store(&out.Status, &out.Error, &out.Time); err != nil {
-			log.Panic(err)
-		}
-		return out
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
 	}
-	if err := call.Store(
+	err = call.Store(
 		&out.Status, &out.Error, &out.Time,
 		&out.Getdevinfo, &out.LayoutGet, &out.LayoutCommit, &out.LayoutReturn, &out.LayoutRecall,
-	); err != nil {
-		log.Panic(err)
+	)
+	return out, err
+}
+
+// Get9pIO returns the 9P IO statistics of an export
+func (mgr ExportMgr) Get9pIO(ctx context.Context, exportID uint32) (BasicStats, error) {
+	out := BasicStats{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.Get9pIO", exportID)
+	if call.Err != nil {
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
+	}
+	err = call.Store(
+		&out.Status, &out.Error, &out.Time,
+		&out.Read, &out.Write,
+	)
+	return out, err
+}
+
+// Get9pTransOps returns the 9P transport statistics of an export
+func (mgr ExportMgr) Get9pTransOps(ctx context.Context, exportID uint32) (TransportStats, error) {
+	out := TransportStats{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.Get9pTransOps", exportID)
+	if call.Err != nil {
+		return out, call.Err
+	}
+	status, err := callStatus(call)
+	if err != nil {
+		return out, err
+	}
+	if !status {
+		err = storeBaseAnswer(call, &out.StatsBaseAnswer)
+		return out, err
+	}
+	err = call.Store(
+		&out.Status, &out.Error, &out.Time,
+		&out.Transport,
+	)
+	return out, err
+}
+
+// GetTotalOPS returns the number of operations of an export for every
+// protocol version
+func (mgr ExportMgr) GetTotalOPS(ctx context.Context, exportID uint32) (TotalOperations, error) {
+	out := TotalOperations{Ops: map[string]uint64{}}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetTotalOPS", exportID)
+	if call.Err != nil {
+		return out, call.Err
+	}
+	err := storeTotalOps(call, &out)
+	return out, err
+}
+
+// GetFULLV3Stats returns the statistics of every NFSv3 operation served
+func (mgr ExportMgr) GetFULLV3Stats(ctx context.Context) (FullOperations, error) {
+	out := FullOperations{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetFULLV3Stats")
+	if call.Err != nil {
+		return out, call.Err
+	}
+	err := storeFullOps(call, &out)
+	return out, err
+}
+
+// GetFULLV4Stats returns the statistics of every NFSv4 operation served
+func (mgr ExportMgr) GetFULLV4Stats(ctx context.Context) (FullV4Operations, error) {
+	out := FullV4Operations{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetFULLV4Stats")
+	if call.Err != nil {
+		return out, call.Err
 	}
-	return out
+	err := storeFullV4Ops(call, &out)
+	return out, err
 }
@@ -0,0 +1,45 @@
+package dbus
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"github.com/godbus/dbus"
+)
+
+// Capabilities is the set of methods ganesha implements, by fully
+// qualified name such as org.ganesha.nfsd.exportstats.GetNFSv3IO
+type Capabilities map[string]bool
+
+// introspection is the part of the introspection data we use
+type introspection struct {
+	Interfaces []struct {
+		Name    string `xml:"name,attr"`
+		Methods []struct {
+			Name string `xml:"name,attr"`
+		} `xml:"method"`
+	} `xml:"interface"`
+}
+
+// Introspect discovers the methods implemented by the ExportMgr, ClientMgr
+// and admin objects of ganesha
+func (c *Conn) Introspect(ctx context.Context) (Capabilities, error) {
+	caps := Capabilities{}
+	for _, path := range []dbus.ObjectPath{exportMgrPath, clientMgrPath, adminPath} {
+		var data string
+		err := c.call(ctx, path, "org.freedesktop.DBus.Introspectable.Introspect").Store(&data)
+		if err != nil {
+			return nil, fmt.Errorf("introspecting %s: %v", path, err)
+		}
+		var node introspection
+		if err = xml.Unmarshal([]byte(data), &node); err != nil {
+			return nil, fmt.Errorf("introspecting %s: %v", path, err)
+		}
+		for _, iface := range node.Interfaces {
+			for _, method := range iface.Methods {
+				caps[iface.Name+"."+method.Name] = true
+			}
+		}
+	}
+	return caps, nil
+}
@@ -0,0 +1,4 @@
+package dbus
+
+// ServiceName is the bus name a running ganesha owns by default
+const ServiceName = "org.ganesha.nfsd"
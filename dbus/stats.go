@@ -1,10 +1,11 @@
 package dbus
 
-import "golang.org/x/sys/unix"
-
-// Gandi variable defines whether we should use Gandi specific struct fields.
-// When set to false, the Gandi specific fields will be empty
-var Gandi = false
+import (
+	"fmt"
+	"github.com/godbus/dbus"
+	"golang.org/x/sys/unix"
+	"strings"
+)
 
 // BasicIO stores the statistics for NFS
 // Each field is a counter
@@ -30,6 +31,16 @@ type LayoutOperationStat struct {
 	Delays uint64
 }
 
+// TransportStat stores statistics for 9P transports
+type TransportStat struct {
+	RxBytes   uint64
+	RxPackets uint64
+	RxErrors  uint64
+	TxBytes   uint64
+	TxPackets uint64
+	TxErrors  uint64
+}
+
 // StatsBaseAnswer is the base answer to stats requests, every
 // statistics related answer begins with this
 type StatsBaseAnswer struct {
@@ -50,6 +61,143 @@ type PNFSOperations struct {
 	LayoutRecall LayoutOperationStat
 }
 
+// TransportStats is the response to 9P transport stats
+// call, depending of the status of the call, Transport
+// may not be filled
+type TransportStats struct {
+	StatsBaseAnswer
+	Transport TransportStat
+}
+
+// TotalOperations is the response to total operations
+// stats call, Ops maps each protocol version to its
+// number of operations and is empty on failure
+type TotalOperations struct {
+	StatsBaseAnswer
+	Ops map[string]uint64
+}
+
+// ProtocolOperationStat stores statistics for a single
+// operation of a protocol
+type ProtocolOperationStat struct {
+	Name   string
+	Total  uint64
+	Errors uint64
+	Dups   uint64
+}
+
+// AllOperations is the response to all operations stats
+// call, the operations of a protocol are only filled when
+// the client uses it
+type AllOperations struct {
+	StatsBaseAnswer
+	NFSv3  []ProtocolOperationStat
+	NFSv4  []ProtocolOperationStat
+	NLMv4  []ProtocolOperationStat
+	MNT    []ProtocolOperationStat
+	RQUOTA []ProtocolOperationStat
+}
+
+// FastOperationStat stores the number of a single operation
+// of a protocol
+type FastOperationStat struct {
+	Protocol string
+	Name     string
+	Total    uint64
+}
+
+// FastOperations is the response to fast operations stats
+// call, Ops is empty on failure
+type FastOperations struct {
+	StatsBaseAnswer
+	Ops []FastOperationStat
+}
+
+// MDCacheStats is the response to metadata cache stats
+// call, the LRU utilization is left empty by the ganesha
+// versions that do not report it
+type MDCacheStats struct {
+	StatsBaseAnswer
+	Requests  uint64
+	Hits      uint64
+	Misses    uint64
+	Conflicts uint64
+	Adds      uint64
+	Mappings  uint64
+	OpenFDs   uint64
+	FDLimit   uint64
+	FDUsage   string
+	Entries   uint64
+	Chunks    uint64
+	// Unknown lists the statistics ganesha reported under a name we
+	// do not know, they are left out
+	Unknown []string
+}
+
+// AuthStat stores statistics for an authentication or
+// name mapping backend, latencies are in milliseconds
+type AuthStat struct {
+	Total uint64
+	Avg   float64
+	Max   float64
+	Min   float64
+}
+
+// AuthStats is the response to auth stats call, the
+// backends are only filled when the call succeeded
+type AuthStats struct {
+	StatsBaseAnswer
+	NameMapping AuthStat
+	Winbind     AuthStat
+	GSS         AuthStat
+}
+
+// StatsStatus stores whether a type of statistics is
+// counted by ganesha, and since when
+type StatsStatus struct {
+	Type    string
+	Enabled bool
+	Since   unix.Timespec
+}
+
+// OperationLatencyStat stores statistics for a single
+// operation, latencies are in milliseconds
+type OperationLatencyStat struct {
+	Name   string
+	Total  uint64
+	Errors uint64
+	Dups   uint64
+	Avg    float64
+	Min    float64
+	Max    float64
+}
+
+// V4OperationLatencyStat stores statistics for a single NFSv4
+// operation, latencies are in milliseconds. Unlike NFSv3, ganesha
+// does not count duplicated NFSv4 requests
+type V4OperationLatencyStat struct {
+	Name   string
+	Total  uint64
+	Errors uint64
+	Avg    float64
+	Min    float64
+	Max    float64
+}
+
+// FullOperations is the response to NFSv3 full stats call,
+// Ops is only filled when the call succeeded
+type FullOperations struct {
+	StatsBaseAnswer
+	Ops []OperationLatencyStat
+}
+
+// FullV4Operations is the response to NFSv4 full stats call,
+// Ops is only filled when the call succeeded
+type FullV4Operations struct {
+	StatsBaseAnswer
+	Ops []V4OperationLatencyStat
+}
+
 // BasicStats is the response to IO stats call,
 // some of the fields may not be filled depending
 // of the call type and status
@@ -61,4 +209,311 @@ type BasicStats struct {
 	Close   OperationStat // Gandi specific
 	Getattr OperationStat // Gandi specific
 	Lock    OperationStat // Gandi specific
+	// Extended tells whether the Gandi specific fields were provided
+	Extended bool
+}
+
+// callStatus returns the Status field every stats answer begins with,
+// without panicking on an unexpected reply
+func callStatus(call *dbus.Call) (bool, error) {
+	if len(call.Body) == 0 {
+		return false, fmt.Errorf("%s: empty reply", call.Method)
+	}
+	status, ok := call.Body[0].(bool)
+	if !ok {
+		return false, fmt.Errorf("%s: unexpected reply signature", call.Method)
+	}
+	return status, nil
+}
+
+// storeBaseAnswer decodes the beginning every stats answer shares, ganesha
+// leaves the time out of some failed answers. The fields following the
+// time, such as the zeroed statistics of a disabled stats type, are ignored
+func storeBaseAnswer(call *dbus.Call, out *StatsBaseAnswer) error {
+	switch {
+	case len(call.Body) >= 3:
+		return dbus.Store(call.Body[:3], &out.Status, &out.Error, &out.Time)
+	case len(call.Body) == 2:
+		return dbus.Store(call.Body, &out.Status, &out.Error)
+	}
+	return fmt.Errorf("%s: unexpected reply signature", call.Method)
+}
+
+// storeBasicStats decodes the answer to an IO stats call, Gandi builds of
+// ganesha follow the read and write statistics by the open, close, getattr
+// and lock operations
+func storeBasicStats(call *dbus.Call, out *BasicStats) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	switch len(call.Body) {
+	case 5:
+		return call.Store(
+			&out.Status, &out.Error, &out.Time,
+			&out.Read, &out.Write,
+		)
+	case 9:
+		out.Extended = true
+		return call.Store(
+			&out.Status, &out.Error, &out.Time,
+			&out.Read, &out.Write,
+			&out.Open, &out.Close, &out.Getattr, &out.Lock,
+		)
+	}
+	return fmt.Errorf("%s: unexpected reply signature", call.Method)
+}
+
+// storeTotalOps decodes the answer to an operations count call, where
+// operations are a struct of alternating names and counters
+func storeTotalOps(call *dbus.Call, out *TotalOperations) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	var ops []interface{}
+	err = call.Store(&out.Status, &out.Error, &out.Time, &ops)
+	if err != nil {
+		return err
+	}
+	for i := 0; i+1 < len(ops); i += 2 {
+		name, ok := ops[i].(string)
+		total, ok2 := ops[i+1].(uint64)
+		if !ok || !ok2 {
+			return fmt.Errorf("%s: unexpected operations signature", call.Method)
+		}
+		out.Ops[strings.TrimSuffix(name, ":")] = total
+	}
+	return nil
+}
+
+// storeAllOps decodes the answer to an all operations call, where every
+// protocol is a flag telling whether the client uses it, followed by its
+// operations
+func storeAllOps(call *dbus.Call, out *AllOperations) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	var used [5]bool
+	var ops [5][]ProtocolOperationStat
+	err = call.Store(
+		&out.Status, &out.Error, &out.Time,
+		&used[0], &ops[0], &used[1], &ops[1], &used[2], &ops[2],
+		&used[3], &ops[3], &used[4], &ops[4],
+	)
+	if err != nil {
+		return err
+	}
+	for i, dest := range []*[]ProtocolOperationStat{&out.NFSv3, &out.NFSv4, &out.NLMv4, &out.MNT, &out.RQUOTA} {
+		if used[i] {
+			*dest = ops[i]
+		}
+	}
+	return nil
+}
+
+// storeFastOps decodes the answer to a fast stats call, where operations
+// are a struct of protocol names ending with ':', each followed by
+// alternating operation names and counters
+func storeFastOps(call *dbus.Call, out *FastOperations) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	var ops []interface{}
+	err = call.Store(&out.Status, &out.Error, &out.Time, &ops)
+	if err != nil {
+		return err
+	}
+	var protocol, name string
+	for _, op := range ops {
+		switch v := op.(type) {
+		case string:
+			if strings.HasSuffix(v, ":") {
+				protocol = strings.TrimSuffix(v, ":")
+			} else {
+				name = v
+			}
+		case uint64:
+			if name == "" {
+				return fmt.Errorf("%s: counter without operation name", call.Method)
+			}
+			out.Ops = append(out.Ops, FastOperationStat{Protocol: protocol, Name: name, Total: v})
+			name = ""
+		default:
+			return fmt.Errorf("%s: unexpected operations signature", call.Method)
+		}
+	}
+	return nil
+}
+
+// storeMDCache decodes the answer to a metadata cache stats call, where the
+// cache counters and the LRU utilization are structs of alternating names
+// and values. Older ganesha versions leave the LRU utilization out
+func storeMDCache(call *dbus.Call, out *MDCacheStats) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	var cache, lru []interface{}
+	if len(call.Body) > 4 {
+		err = call.Store(&out.Status, &out.Error, &out.Time, &cache, &lru)
+	} else {
+		err = call.Store(&out.Status, &out.Error, &out.Time, &cache)
+	}
+	if err != nil {
+		return err
+	}
+	for _, fields := range [][]interface{}{cache, lru} {
+		for i := 0; i+1 < len(fields); i += 2 {
+			name, ok := fields[i].(string)
+			if !ok {
+				return fmt.Errorf("%s: unexpected cache signature", call.Method)
+			}
+			name = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(name), ":"))
+			field := mdcacheField(out, name)
+			switch v := fields[i+1].(type) {
+			case uint64:
+				if field != nil {
+					*field = v
+					continue
+				}
+			case uint32:
+				if field != nil {
+					*field = uint64(v)
+					continue
+				}
+			case string:
+				if name == "FD usage" {
+					out.FDUsage = strings.TrimSpace(v)
+					continue
+				}
+			}
+			out.Unknown = append(out.Unknown, name)
+		}
+	}
+	return nil
+}
+
+// mdcacheField returns the field of out ganesha reports as name, or nil for
+// the names we do not know. Names are given without their padding and colon
+func mdcacheField(out *MDCacheStats, name string) *uint64 {
+	switch name {
+	case "Cache Requests":
+		return &out.Requests
+	case "Cache Hits":
+		return &out.Hits
+	case "Cache Misses":
+		return &out.Misses
+	case "Cache Conflicts":
+		return &out.Conflicts
+	case "Cache Adds":
+		return &out.Adds
+	case "Cache Mapping", "Cache Mappings":
+		return &out.Mappings
+	case "FSAL opened FD count":
+		return &out.OpenFDs
+	case "System limit on FDs":
+		return &out.FDLimit
+	case "LRU entries in use":
+		return &out.Entries
+	case "Chunks in use":
+		return &out.Chunks
+	}
+	return nil
+}
+
+// storeFullOps decodes the answer to an NFSv3 full stats call
+func storeFullOps(call *dbus.Call, out *FullOperations) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	return call.Store(&out.Status, &out.Error, &out.Time, &out.Ops)
+}
+
+// storeFullV4Ops decodes the answer to an NFSv4 full stats call, whose
+// operations have no duplicated requests counter
+func storeFullV4Ops(call *dbus.Call, out *FullV4Operations) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	return call.Store(&out.Status, &out.Error, &out.Time, &out.Ops)
+}
+
+// storeAuthStats decodes the answer to an auth stats call, where the
+// backends are flattened in a single struct
+func storeAuthStats(call *dbus.Call, out *AuthStats) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		return storeBaseAnswer(call, &out.StatsBaseAnswer)
+	}
+	var backends []interface{}
+	err = call.Store(&out.Status, &out.Error, &out.Time, &backends)
+	if err != nil {
+		return err
+	}
+	return dbus.Store(
+		backends,
+		&out.NameMapping.Total, &out.NameMapping.Avg, &out.NameMapping.Max, &out.NameMapping.Min,
+		&out.Winbind.Total, &out.Winbind.Avg, &out.Winbind.Max, &out.Winbind.Min,
+		&out.GSS.Total, &out.GSS.Avg, &out.GSS.Max, &out.GSS.Min,
+	)
+}
+
+// storeStatsStatus decodes the answer to a stats status call, where every
+// type is a struct of its status and of the time it was enabled, following
+// the status of the call and its error message. A failed answer is an error
+func storeStatsStatus(call *dbus.Call, out *[]StatsStatus) error {
+	status, err := callStatus(call)
+	if err != nil {
+		return err
+	}
+	if !status {
+		answer := StatsBaseAnswer{}
+		if err = storeBaseAnswer(call, &answer); err != nil {
+			return err
+		}
+		return fmt.Errorf("%s: %s", call.Method, answer.Error)
+	}
+	var statuses []StatsStatus
+	for i := 2; i < len(call.Body) && i-2 < len(StatsTypes); i++ {
+		s := StatsStatus{Type: StatsTypes[i-2]}
+		fields, ok := call.Body[i].([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unexpected %s status signature", call.Method, s.Type)
+		}
+		if err = dbus.Store(fields, &s.Enabled, &s.Since); err != nil {
+			return fmt.Errorf("%s: %s status: %v", call.Method, s.Type, err)
+		}
+		statuses = append(statuses, s)
+	}
+	*out = statuses
+	return nil
 }
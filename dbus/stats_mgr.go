@@ -0,0 +1,134 @@
+package dbus
+
+import (
+	"context"
+	"fmt"
+)
+
+// StatsMgr is a handle to the server-wide statistics, ganesha serves them
+// on the ExportMgr dbus object
+type StatsMgr struct {
+	conn *Conn
+}
+
+// NewStatsMgr Get a new StatsMgr using conn
+func NewStatsMgr(conn *Conn) StatsMgr {
+	return StatsMgr{conn: conn}
+}
+
+// GetGlobalOPS returns the number of operations served for every protocol
+// version
+func (mgr StatsMgr) GetGlobalOPS(ctx context.Context) (TotalOperations, error) {
+	out := TotalOperations{Ops: map[string]uint64{}}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetGlobalOPS")
+	if call.Err != nil {
+		return out, call.Err
+	}
+	err := storeTotalOps(call, &out)
+	return out, err
+}
+
+// ShowFastOPS returns the number of every operation served, as counted by
+// the lock-free fast stats
+func (mgr StatsMgr) ShowFastOPS(ctx context.Context) (FastOperations, error) {
+	out := FastOperations{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.ShowFastOPS")
+	if call.Err != nil {
+		return out, call.Err
+	}
+	err := storeFastOps(call, &out)
+	return out, err
+}
+
+// ShowMDCache returns the metadata cache statistics, falling back to the
+// ShowCacheInode name used by older ganesha versions
+func (mgr StatsMgr) ShowMDCache(ctx context.Context) (MDCacheStats, error) {
+	out := MDCacheStats{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.ShowMDCache")
+	if IsUnknownMethod(call.Err) {
+		call = mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.ShowCacheInode")
+	}
+	if call.Err != nil {
+		return out, call.Err
+	}
+	err := storeMDCache(call, &out)
+	return out, err
+}
+
+// GetAuthStats returns the statistics of the name mapping, winbind and
+// gss lookups
+func (mgr StatsMgr) GetAuthStats(ctx context.Context) (AuthStats, error) {
+	out := AuthStats{}
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.GetAuthStats")
+	if call.Err != nil {
+		return out, call.Err
+	}
+	err := storeAuthStats(call, &out)
+	return out, err
+}
+
+// StatsTypes lists the types of statistics, in the order StatusStats
+// reports them
+var StatsTypes = []string{"nfs", "fsal", "v3_full", "v4_full", "auth", "client_all_ops"}
+
+// StatusStats returns whether each type of statistics is counted, older
+// ganesha versions only report the first types
+func (mgr StatsMgr) StatusStats(ctx context.Context) ([]StatsStatus, error) {
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.StatusStats")
+	if call.Err != nil {
+		return nil, call.Err
+	}
+	var statuses []StatsStatus
+	err := storeStatsStatus(call, &statuses)
+	return statuses, err
+}
+
+// EnableStats enables counting statsType statistics, one of StatsTypes
+// or "all"
+func (mgr StatsMgr) EnableStats(ctx context.Context, statsType string) error {
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.EnableStats", statsType)
+	if call.Err != nil {
+		return call.Err
+	}
+	out := StatsBaseAnswer{}
+	if err := storeBaseAnswer(call, &out); err != nil {
+		return err
+	}
+	if !out.Status {
+		return fmt.Errorf("%s(%s): %s", call.Method, statsType, out.Error)
+	}
+	return nil
+}
+
+// DisableStats stops ganesha from counting the statsType statistics, one
+// of StatsTypes or all
+func (mgr StatsMgr) DisableStats(ctx context.Context, statsType string) error {
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.DisableStats", statsType)
+	if call.Err != nil {
+		return call.Err
+	}
+	out := StatsBaseAnswer{}
+	if err := storeBaseAnswer(call, &out); err != nil {
+		return err
+	}
+	if !out.Status {
+		return fmt.Errorf("%s(%s): %s", call.Method, statsType, out.Error)
+	}
+	return nil
+}
+
+// ResetStats resets every statistics counter of ganesha
+func (mgr StatsMgr) ResetStats(ctx context.Context) error {
+	call := mgr.conn.call(ctx, exportMgrPath, "org.ganesha.nfsd.exportstats.ResetStats")
+	if call.Err != nil {
+		return call.Err
+	}
+	out := StatsBaseAnswer{}
+	if err := storeBaseAnswer(call, &out); err != nil {
+		return err
+	}
+	if !out.Status {
+		return fmt.Errorf("%s: %s", call.Method, out.Error)
+	}
+	return nil
+}
@@ -0,0 +1,553 @@
+package dbus
+
+import (
+	"github.com/godbus/dbus"
+	"golang.org/x/sys/unix"
+	"reflect"
+	"testing"
+)
+
+// the replies below are built the way godbus decodes them, structs are
+// slices of interfaces and arrays of structs are slices of those
+
+var (
+	replyTime = []interface{}{uint64(1500000000), uint64(42)}
+	storeTime = unix.Timespec{Sec: 1500000000, Nsec: 42}
+)
+
+func basicIO(n uint64) []interface{} {
+	return []interface{}{n, n + 1, n + 2, n + 3, n + 4, n + 5}
+}
+
+func TestStoreBasicStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    BasicStats
+		wantErr bool
+	}{
+		{
+			name: "standard",
+			body: []interface{}{true, "OK", replyTime, basicIO(10), basicIO(20)},
+			want: BasicStats{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				Read:            BasicIO{10, 11, 12, 13, 14, 15},
+				Write:           BasicIO{20, 21, 22, 23, 24, 25},
+			},
+		},
+		{
+			name: "gandi",
+			body: []interface{}{true, "OK", replyTime, basicIO(10), basicIO(20),
+				[]interface{}{uint64(1), uint64(2)}, []interface{}{uint64(3), uint64(4)},
+				[]interface{}{uint64(5), uint64(6)}, []interface{}{uint64(7), uint64(8)}},
+			want: BasicStats{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				Read:            BasicIO{10, 11, 12, 13, 14, 15},
+				Write:           BasicIO{20, 21, 22, 23, 24, 25},
+				Open:            OperationStat{1, 2},
+				Close:           OperationStat{3, 4},
+				Getattr:         OperationStat{5, 6},
+				Lock:            OperationStat{7, 8},
+				Extended:        true,
+			},
+		},
+		{
+			name: "failed status",
+			body: []interface{}{false, "Export does not have any NFSv4.1 activity", replyTime, basicIO(0), basicIO(0)},
+			want: BasicStats{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "Export does not have any NFSv4.1 activity", Time: storeTime},
+			},
+		},
+		{
+			name:    "malformed",
+			body:    []interface{}{true, "OK", replyTime, basicIO(10)},
+			wantErr: true,
+		},
+		{
+			name:    "malformed status",
+			body:    []interface{}{"OK", true, replyTime, basicIO(10), basicIO(20)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := BasicStats{}
+			err := storeBasicStats(&dbus.Call{Method: "GetNFSv41IO", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeBasicStats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeBasicStats() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreFullOps(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    FullOperations
+		wantErr bool
+	}{
+		{
+			name: "standard",
+			body: []interface{}{true, "OK", replyTime, [][]interface{}{
+				{"LOOKUP", uint64(100), uint64(3), uint64(1), 0.5, 0.1, 2.5},
+			}},
+			want: FullOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				Ops: []OperationLatencyStat{
+					{Name: "LOOKUP", Total: 100, Errors: 3, Dups: 1, Avg: 0.5, Min: 0.1, Max: 2.5},
+				},
+			},
+		},
+		{
+			name: "failed status",
+			body: []interface{}{false, "NFSv3 Full stats disabled", replyTime, [][]interface{}{}},
+			want: FullOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "NFSv3 Full stats disabled", Time: storeTime},
+			},
+		},
+		{
+			name: "nfsv4 reply",
+			body: []interface{}{true, "OK", replyTime, [][]interface{}{
+				{"OPEN", uint64(20), uint64(1), 0.3, 0.1, 0.9},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := FullOperations{}
+			err := storeFullOps(&dbus.Call{Method: "GetFULLV3Stats", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeFullOps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeFullOps() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreFullV4Ops(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    FullV4Operations
+		wantErr bool
+	}{
+		{
+			name: "standard",
+			body: []interface{}{true, "OK", replyTime, [][]interface{}{
+				{"OPEN", uint64(20), uint64(1), 0.3, 0.1, 0.9},
+				{"CLOSE", uint64(18), uint64(0), 0.2, 0.1, 0.4},
+			}},
+			want: FullV4Operations{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				Ops: []V4OperationLatencyStat{
+					{Name: "OPEN", Total: 20, Errors: 1, Avg: 0.3, Min: 0.1, Max: 0.9},
+					{Name: "CLOSE", Total: 18, Errors: 0, Avg: 0.2, Min: 0.1, Max: 0.4},
+				},
+			},
+		},
+		{
+			name: "failed status",
+			body: []interface{}{false, "NFSv4 Full stats disabled", replyTime, [][]interface{}{}},
+			want: FullV4Operations{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "NFSv4 Full stats disabled", Time: storeTime},
+			},
+		},
+		{
+			name: "nfsv3 reply",
+			body: []interface{}{true, "OK", replyTime, [][]interface{}{
+				{"LOOKUP", uint64(100), uint64(3), uint64(1), 0.5, 0.1, 2.5},
+			}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := FullV4Operations{}
+			err := storeFullV4Ops(&dbus.Call{Method: "GetFULLV4Stats", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeFullV4Ops() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeFullV4Ops() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreTotalOps(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    TotalOperations
+		wantErr bool
+	}{
+		{
+			name: "standard",
+			body: []interface{}{true, "OK", replyTime,
+				[]interface{}{"NFSv3:", uint64(300), "NFSv40:", uint64(70), "NLM:", uint64(10)}},
+			want: TotalOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				Ops:             map[string]uint64{"NFSv3": 300, "NFSv40": 70, "NLM": 10},
+			},
+		},
+		{
+			name: "failed status",
+			body: []interface{}{false, "Stats disabled", replyTime},
+			want: TotalOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "Stats disabled", Time: storeTime},
+				Ops:             map[string]uint64{},
+			},
+		},
+		{
+			name:    "malformed",
+			body:    []interface{}{true, "OK", replyTime, []interface{}{"NFSv3:", "300"}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := TotalOperations{Ops: map[string]uint64{}}
+			err := storeTotalOps(&dbus.Call{Method: "GetGlobalOPS", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeTotalOps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeTotalOps() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreAllOps(t *testing.T) {
+	op := func(name string, n uint64) []interface{} {
+		return []interface{}{name, n, n + 1, n + 2}
+	}
+	none := [][]interface{}{}
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    AllOperations
+		wantErr bool
+	}{
+		{
+			name: "every protocol",
+			body: []interface{}{true, "OK", replyTime,
+				true, [][]interface{}{op("READ", 10)},
+				true, [][]interface{}{op("OPEN", 20), op("CLOSE", 30)},
+				true, [][]interface{}{op("LOCK", 40)},
+				true, [][]interface{}{op("MNT", 50)},
+				true, [][]interface{}{op("GETQUOTA", 60)}},
+			want: AllOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				NFSv3:           []ProtocolOperationStat{{"READ", 10, 11, 12}},
+				NFSv4:           []ProtocolOperationStat{{"OPEN", 20, 21, 22}, {"CLOSE", 30, 31, 32}},
+				NLMv4:           []ProtocolOperationStat{{"LOCK", 40, 41, 42}},
+				MNT:             []ProtocolOperationStat{{"MNT", 50, 51, 52}},
+				RQUOTA:          []ProtocolOperationStat{{"GETQUOTA", 60, 61, 62}},
+			},
+		},
+		{
+			name: "nfsv4 only client",
+			body: []interface{}{true, "OK", replyTime,
+				false, none,
+				true, [][]interface{}{op("OPEN", 20)},
+				false, none,
+				false, none,
+				false, none},
+			want: AllOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				NFSv4:           []ProtocolOperationStat{{"OPEN", 20, 21, 22}},
+			},
+		},
+		{
+			name: "side protocols disabled",
+			body: []interface{}{true, "OK", replyTime,
+				true, [][]interface{}{op("READ", 10)},
+				true, [][]interface{}{op("OPEN", 20)},
+				false, [][]interface{}{op("LOCK", 0)},
+				false, none,
+				false, none},
+			want: AllOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				NFSv3:           []ProtocolOperationStat{{"READ", 10, 11, 12}},
+				NFSv4:           []ProtocolOperationStat{{"OPEN", 20, 21, 22}},
+			},
+		},
+		{
+			name: "failed status",
+			body: []interface{}{false, "Client all ops stats disabled", replyTime},
+			want: AllOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "Client all ops stats disabled", Time: storeTime},
+			},
+		},
+		{
+			name:    "missing protocol",
+			body:    []interface{}{true, "OK", replyTime, true, [][]interface{}{op("READ", 10)}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := AllOperations{}
+			err := storeAllOps(&dbus.Call{Method: "GetClientAllops", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeAllOps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeAllOps() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreFastOps(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    FastOperations
+		wantErr bool
+	}{
+		{
+			name: "standard",
+			body: []interface{}{true, "OK", replyTime, []interface{}{
+				"NFSv3:", "GETATTR", uint64(12), "LOOKUP", uint64(40),
+				"NFSv4:", "COMPOUND", uint64(99),
+			}},
+			want: FastOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				Ops: []FastOperationStat{
+					{Protocol: "NFSv3", Name: "GETATTR", Total: 12},
+					{Protocol: "NFSv3", Name: "LOOKUP", Total: 40},
+					{Protocol: "NFSv4", Name: "COMPOUND", Total: 99},
+				},
+			},
+		},
+		{
+			name: "failed status",
+			body: []interface{}{false, "Stats disabled", replyTime},
+			want: FastOperations{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "Stats disabled", Time: storeTime},
+			},
+		},
+		{
+			name:    "counter without name",
+			body:    []interface{}{true, "OK", replyTime, []interface{}{"NFSv3:", uint64(12)}},
+			wantErr: true,
+		},
+		{
+			name:    "malformed",
+			body:    []interface{}{true, "OK", replyTime, []interface{}{"NFSv3:", "GETATTR", int32(12)}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := FastOperations{}
+			err := storeFastOps(&dbus.Call{Method: "ShowFastOPS", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeFastOps() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeFastOps() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreMDCache(t *testing.T) {
+	cache := []interface{}{
+		" Cache Requests: ", uint64(1000), " Cache Hits: ", uint64(900),
+		" Cache Misses: ", uint64(100), " Cache Conflicts: ", uint64(2),
+		" Cache Adds: ", uint64(98), " Cache Mapping: ", uint64(120),
+	}
+	lru := []interface{}{
+		" FSAL opened FD count : ", uint64(42), " System limit on FDs : ", uint32(4096),
+		" FD usage : ", " Below Low Water Mark ", " LRU entries in use : ", uint64(5000),
+		" Chunks in use : ", uint64(12),
+	}
+	counters := MDCacheStats{
+		StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+		Requests:        1000,
+		Hits:            900,
+		Misses:          100,
+		Conflicts:       2,
+		Adds:            98,
+		Mappings:        120,
+	}
+	withLRU := counters
+	withLRU.OpenFDs = 42
+	withLRU.FDLimit = 4096
+	withLRU.FDUsage = "Below Low Water Mark"
+	withLRU.Entries = 5000
+	withLRU.Chunks = 12
+	withUnknown := counters
+	withUnknown.Unknown = []string{"Cache Evictions"}
+
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    MDCacheStats
+		wantErr bool
+	}{
+		{
+			name: "with lru",
+			body: []interface{}{true, "OK", replyTime, cache, lru},
+			want: withLRU,
+		},
+		{
+			name: "without lru",
+			body: []interface{}{true, "OK", replyTime, cache},
+			want: counters,
+		},
+		{
+			name: "unknown statistic",
+			body: []interface{}{true, "OK", replyTime, append([]interface{}{" Cache Evictions: ", uint64(7)}, cache...)},
+			want: withUnknown,
+		},
+		{
+			name: "failed status",
+			body: []interface{}{false, "Cache stats disabled", replyTime},
+			want: MDCacheStats{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "Cache stats disabled", Time: storeTime},
+			},
+		},
+		{
+			name:    "malformed",
+			body:    []interface{}{true, "OK", replyTime, []interface{}{uint64(1000), " Cache Requests: "}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := MDCacheStats{}
+			err := storeMDCache(&dbus.Call{Method: "ShowMDCache", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeMDCache() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeMDCache() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreAuthStats(t *testing.T) {
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    AuthStats
+		wantErr bool
+	}{
+		{
+			name: "standard",
+			body: []interface{}{true, "OK", replyTime, []interface{}{
+				uint64(10), 1.5, 3.0, 0.5,
+				uint64(20), 2.5, 4.0, 1.5,
+				uint64(30), 3.5, 5.0, 2.5,
+			}},
+			want: AuthStats{
+				StatsBaseAnswer: StatsBaseAnswer{Status: true, Error: "OK", Time: storeTime},
+				NameMapping:     AuthStat{Total: 10, Avg: 1.5, Max: 3, Min: 0.5},
+				Winbind:         AuthStat{Total: 20, Avg: 2.5, Max: 4, Min: 1.5},
+				GSS:             AuthStat{Total: 30, Avg: 3.5, Max: 5, Min: 2.5},
+			},
+		},
+		{
+			name: "stats disabled",
+			body: []interface{}{false, "auth related stats disabled"},
+			want: AuthStats{
+				StatsBaseAnswer: StatsBaseAnswer{Error: "auth related stats disabled"},
+			},
+		},
+		{
+			name:    "missing backend",
+			body:    []interface{}{true, "OK", replyTime, []interface{}{uint64(10), 1.5, 3.0, 0.5}},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := AuthStats{}
+			err := storeAuthStats(&dbus.Call{Method: "GetAuthStats", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeAuthStats() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeAuthStats() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
+
+func TestStoreStatsStatus(t *testing.T) {
+	enabled := []interface{}{true, replyTime}
+	disabled := []interface{}{false, []interface{}{uint64(0), uint64(0)}}
+	tests := []struct {
+		name    string
+		body    []interface{}
+		want    []StatsStatus
+		wantErr bool
+	}{
+		{
+			name: "every type",
+			body: []interface{}{true, "OK", enabled, enabled, disabled, disabled, enabled, disabled},
+			want: []StatsStatus{
+				{Type: "nfs", Enabled: true, Since: storeTime},
+				{Type: "fsal", Enabled: true, Since: storeTime},
+				{Type: "v3_full"},
+				{Type: "v4_full"},
+				{Type: "auth", Enabled: true, Since: storeTime},
+				{Type: "client_all_ops"},
+			},
+		},
+		{
+			name: "older ganesha",
+			body: []interface{}{true, "OK", enabled, disabled},
+			want: []StatsStatus{
+				{Type: "nfs", Enabled: true, Since: storeTime},
+				{Type: "fsal"},
+			},
+		},
+		{
+			name: "stats disabled",
+			body: []interface{}{true, "OK", disabled, disabled, disabled, disabled, disabled, disabled},
+			want: []StatsStatus{
+				{Type: "nfs"},
+				{Type: "fsal"},
+				{Type: "v3_full"},
+				{Type: "v4_full"},
+				{Type: "auth"},
+				{Type: "client_all_ops"},
+			},
+		},
+		{
+			name:    "failed status",
+			body:    []interface{}{false, "Unable to get stats status"},
+			wantErr: true,
+		},
+		{
+			name:    "malformed type",
+			body:    []interface{}{true, "OK", enabled, true},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var out []StatsStatus
+			err := storeStatsStatus(&dbus.Call{Method: "StatusStats", Body: tt.body}, &out)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("storeStatsStatus() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(out, tt.want) {
+				t.Errorf("storeStatsStatus() = %+v, want %+v", out, tt.want)
+			}
+		})
+	}
+}
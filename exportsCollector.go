@@ -1,13 +1,26 @@
 package main
 
 import (
+	"context"
+	"fmt"
 	"github.com/Gandi/ganesha_exporter/dbus"
 	"github.com/prometheus/client_golang/prometheus"
 	"gopkg.in/alecthomas/kingpin.v2"
 	"strconv"
+	"strings"
 )
 
 var (
+	exportInfoDesc = prometheus.NewDesc(
+		"ganesha_export_info",
+		"Protocols the export is active on",
+		[]string{"exportid", "path", "nfsv3", "mntv3", "nlmv4", "rquota", "nfsv40", "nfsv41", "nfsv42", "plan9"}, nil,
+	)
+	exportLastActivityDesc = prometheus.NewDesc(
+		"ganesha_export_last_activity_timestamp_seconds",
+		"Time of the last activity of the export",
+		[]string{"exportid", "path"}, nil,
+	)
 	nfsV3RequestedDesc = prometheus.NewDesc(
 		"ganesha_exports_nfs_v3_requested_bytes_total",
 		"Number of requested bytes for NFSv3 operations",
@@ -98,6 +111,16 @@ var (
 		"Cumulative time spent in rpc wait queue for NFSv4.1",
 		[]string{"direction", "exportid", "path"}, nil,
 	)
+	nfsV41OpsDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v41_ops_total",
+		"Number of NFSv4.1 operations, Gandi specific",
+		[]string{"op", "exportid", "path"}, nil,
+	)
+	nfsV41OpsErrorsDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v41_ops_errors_total",
+		"Number of NFSv4.1 operations in error, Gandi specific",
+		[]string{"op", "exportid", "path"}, nil,
+	)
 	pnfsLayoutOperationsDesc = prometheus.NewDesc(
 		"ganesha_exports_pnfs_v41_layout_operations_total",
 		"Numer of layout operations for pNFSv4.1",
@@ -113,315 +136,902 @@ var (
 		"Cumulative delay time for pNFSv4.1",
 		[]string{"direction", "exportid", "path"}, nil,
 	)
+	nfsV42RequestedDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v42_requested_bytes_total",
+		"Number of requested bytes for NFSv4.2 operations",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	nfsV42TransferedDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v42_transfered_bytes_total",
+		"Number of transfered bytes for NFSv4.2 operations",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	nfsV42OperationsDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v42_operations_total",
+		"Number of operations for NFSv4.2",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	nfsV42ErrorsDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v42_operations_errors_total",
+		"Number of operations in error for NFSv4.2",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	nfsV42LatencyDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v42_operations_latency_seconds_total",
+		"Cumulative time consumed by operations for NFSv4.2",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	nfsV42QueueWaitDesc = prometheus.NewDesc(
+		"ganesha_exports_nfs_v42_operations_queue_wait_seconds_total",
+		"Cumulative time spent in rpc wait queue for NFSv4.2",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	pnfsV42LayoutOperationsDesc = prometheus.NewDesc(
+		"ganesha_exports_pnfs_v42_layout_operations_total",
+		"Numer of layout operations for pNFSv4.2",
+		[]string{"type", "exportid", "path"}, nil,
+	)
+	pnfsV42LayoutErrorsDesc = prometheus.NewDesc(
+		"ganesha_exports_pnfs_v42_layout_operations_errors_total",
+		"Numer of layout operations in error for pNFSv4.2",
+		[]string{"type", "exportid", "path"}, nil,
+	)
+	pnfsV42LayoutDelayDesc = prometheus.NewDesc(
+		"ganesha_exports_pnfs_v42_layout_delay_seconds_total",
+		"Cumulative delay time for pNFSv4.2",
+		[]string{"type", "exportid", "path"}, nil,
+	)
+	plan9RequestedDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_requested_bytes_total",
+		"Number of requested bytes for 9P operations",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9TransferedDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_transfered_bytes_total",
+		"Number of transfered bytes for 9P operations",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9OperationsDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_operations_total",
+		"Number of operations for 9P",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9ErrorsDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_operations_errors_total",
+		"Number of operations in error for 9P",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9LatencyDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_operations_latency_seconds_total",
+		"Cumulative time consumed by operations for 9P",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9QueueWaitDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_operations_queue_wait_seconds_total",
+		"Cumulative time spent in rpc wait queue for 9P",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9TransportBytesDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_transport_bytes_total",
+		"Number of bytes handled by 9P transports",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9TransportPacketsDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_transport_packets_total",
+		"Number of packets handled by 9P transports",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	plan9TransportErrorsDesc = prometheus.NewDesc(
+		"ganesha_exports_9p_transport_errors_total",
+		"Number of transport errors for 9P",
+		[]string{"direction", "exportid", "path"}, nil,
+	)
+	mntOperationsDesc = prometheus.NewDesc(
+		"ganesha_exports_mnt_operations_total",
+		"Number of MNT operations",
+		[]string{"exportid", "path"}, nil,
+	)
+	nlmOperationsDesc = prometheus.NewDesc(
+		"ganesha_exports_nlm_operations_total",
+		"Number of NLM operations",
+		[]string{"exportid", "path"}, nil,
+	)
+	rquotaOperationsDesc = prometheus.NewDesc(
+		"ganesha_exports_rquota_operations_total",
+		"Number of RQUOTA operations",
+		[]string{"exportid", "path"}, nil,
+	)
+)
+
+var (
+	exportsEnabled = kingpin.Flag("collector.exports", "Activate exports collector").Default("true").Bool()
+	exportsNFSv3   = kingpin.Flag("collector.exports.nfsv3", "Activate NFSv3 stats").Default("true").Bool()
+	exportsNFSv40  = kingpin.Flag("collector.exports.nfsv40", "Activate NFSv4.0 stats").Default("true").Bool()
+	exportsNFSv41  = kingpin.Flag("collector.exports.nfsv41", "Activate NFSv4.1 stats").Default("true").Bool()
+	exportsPNFSv41 = kingpin.Flag("collector.exports.pnfsv41", "Activate pNFSv4.1 stats").Default("true").Bool()
+	exportsNFSv42  = kingpin.Flag("collector.exports.nfsv42", "Activate NFSv4.2 stats").Default("true").Bool()
+	exportsPNFSv42 = kingpin.Flag("collector.exports.pnfsv42", "Activate pNFSv4.2 stats, ganesha must provide GetNFSv42Layouts").Default("false").Bool()
+	exportsPlan9   = kingpin.Flag("collector.exports.9p", "Activate 9P stats").Default("true").Bool()
+	exportsMNT     = kingpin.Flag("collector.exports.mnt", "Activate MNT stats").Default("true").Bool()
+	exportsNLM     = kingpin.Flag("collector.exports.nlm", "Activate NLM stats").Default("true").Bool()
+	exportsRQUOTA  = kingpin.Flag("collector.exports.rquota", "Activate RQUOTA stats").Default("true").Bool()
 )
 
+// exportStats holds the statistics of one export, a zero value is kept for
+// the protocol families that are disabled or not served
+type exportStats struct {
+	nfsv3, nfsv40, nfsv41, nfsv42             dbus.BasicStats
+	pnfsv41, pnfsv42                          dbus.PNFSOperations
+	nfsv3Err, nfsv40Err, nfsv41Err, nfsv42Err error
+	pnfsv41Err, pnfsv42Err                    error
+	plan9                                     dbus.BasicStats
+	plan9Trans                                dbus.TransportStats
+	plan9Err, plan9TransErr                   error
+	ops                                       dbus.TotalOperations
+	opsErr                                    error
+}
+
 // ExportsCollector Collector for ganesha exports
 type ExportsCollector struct {
-	exportMgr                      dbus.ExportMgr
-	nfsv3, nfsv40, nfsv41, pnfsv41 *bool
+	exportMgr dbus.ExportMgr
+	protocols
+	activeFilter
+	sampleTime
+	workers int
+	errors  prometheus.Counter
+}
+
+// exportsProtocols returns the protocol families enabled by flags
+func exportsProtocols() protocols {
+	return protocols{
+		nfsv3:   *exportsNFSv3,
+		nfsv40:  *exportsNFSv40,
+		nfsv41:  *exportsNFSv41,
+		pnfsv41: *exportsPNFSv41,
+		nfsv42:  *exportsNFSv42,
+		pnfsv42: *exportsPNFSv42,
+		plan9:   *exportsPlan9,
+		mnt:     *exportsMNT,
+		nlm:     *exportsNLM,
+		rquota:  *exportsRQUOTA,
+	}
 }
 
-// NewExportsCollector creates a new collector
-func NewExportsCollector() ExportsCollector {
+// NewExportsCollector creates a new collector gathering the protocol families in p,
+// with at most workers concurrent calls. With activeOnly, the families an export
+// is not active on are left out, and with timestamps the metrics carry the time
+// ganesha sampled them
+func NewExportsCollector(conn *dbus.Conn, p protocols, workers int, activeOnly, timestamps bool) ExportsCollector {
 	return ExportsCollector{
-		exportMgr: dbus.NewExportMgr(),
-		nfsv3:     kingpin.Flag("collector.exports.nfsv3", "Activate NFSv3 stats").Default("true").Bool(),
-		nfsv40:    kingpin.Flag("collector.exports.nfsv40", "Activate NFSv4.0 stats").Default("true").Bool(),
-		nfsv41:    kingpin.Flag("collector.exports.nfsv41", "Activate NFSv4.1 stats").Default("true").Bool(),
-		pnfsv41:   kingpin.Flag("collector.exports.pnfsv41", "Activate pNFSv4.1 stats").Default("true").Bool(),
+		exportMgr:    dbus.NewExportMgr(conn),
+		protocols:    p,
+		activeFilter: newActiveFilter(activeOnly, "exports"),
+		workers:      workers,
+		sampleTime:   sampleTime(timestamps),
+		errors:       newDBusErrorsCounter("exports"),
 	}
 }
 
 // Describe prometheus description
 func (ic ExportsCollector) Describe(ch chan<- *prometheus.Desc) {
-	prometheus.DescribeByCollect(ic, ch)
+	ch <- exportInfoDesc
+	ch <- exportLastActivityDesc
+	ch <- nfsV3RequestedDesc
+	ch <- nfsV3TransferedDesc
+	ch <- nfsV3OperationsDesc
+	ch <- nfsV3ErrorsDesc
+	ch <- nfsV3LatencyDesc
+	ch <- nfsV3QueueWaitDesc
+	ch <- nfsV40RequestedDesc
+	ch <- nfsV40TransferedDesc
+	ch <- nfsV40OperationsDesc
+	ch <- nfsV40ErrorsDesc
+	ch <- nfsV40LatencyDesc
+	ch <- nfsV40QueueWaitDesc
+	ch <- nfsV41RequestedDesc
+	ch <- nfsV41TransferedDesc
+	ch <- nfsV41OperationsDesc
+	ch <- nfsV41ErrorsDesc
+	ch <- nfsV41LatencyDesc
+	ch <- nfsV41QueueWaitDesc
+	ch <- nfsV41OpsDesc
+	ch <- nfsV41OpsErrorsDesc
+	ch <- pnfsLayoutOperationsDesc
+	ch <- pnfsLayoutErrorsDesc
+	ch <- pnfsLayoutDelayDesc
+	ch <- nfsV42RequestedDesc
+	ch <- nfsV42TransferedDesc
+	ch <- nfsV42OperationsDesc
+	ch <- nfsV42ErrorsDesc
+	ch <- nfsV42LatencyDesc
+	ch <- nfsV42QueueWaitDesc
+	ch <- pnfsV42LayoutOperationsDesc
+	ch <- pnfsV42LayoutErrorsDesc
+	ch <- pnfsV42LayoutDelayDesc
+	ch <- plan9RequestedDesc
+	ch <- plan9TransferedDesc
+	ch <- plan9OperationsDesc
+	ch <- plan9ErrorsDesc
+	ch <- plan9LatencyDesc
+	ch <- plan9QueueWaitDesc
+	ch <- plan9TransportBytesDesc
+	ch <- plan9TransportPacketsDesc
+	ch <- plan9TransportErrorsDesc
+	ch <- mntOperationsDesc
+	ch <- nlmOperationsDesc
+	ch <- rquotaOperationsDesc
+	ic.errors.Describe(ch)
+	ic.statsErrors.Describe(ch)
 }
 
-// Collect do the actual job
-func (ic ExportsCollector) Collect(ch chan<- prometheus.Metric) {
-	var ()
-	_, exports := ic.exportMgr.ShowExports()
-	for _, export := range exports {
+// CollectErrors sends the error counters
+func (ic ExportsCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	ic.errors.Collect(ch)
+	ic.statsErrors.Collect(ch)
+}
+
+// Update do the actual job
+func (ic ExportsCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	_, exports, err := ic.exportMgr.ShowExports(ctx)
+	if err != nil {
+		countError(ic.errors, err, "ShowExports")
+		return err
+	}
+	results := make([]exportStats, len(exports))
+	parallel(ic.workers, len(exports), func(i int) {
+		results[i] = ic.fetch(ctx, exports[i])
+	})
+	for i, export := range exports {
+		result := results[i]
 		exportid := strconv.FormatUint(uint64(export.ExportID), 10)
 		path := export.Path
-		if *ic.nfsv3 {
-			var stats dbus.BasicStats
-			if export.NFSv3 {
-				stats = ic.exportMgr.GetNFSv3IO(export.ExportID)
+		ch <- prometheus.MustNewConstMetric(
+			exportInfoDesc,
+			prometheus.GaugeValue,
+			1,
+			exportid, path,
+			strconv.FormatBool(export.NFSv3), strconv.FormatBool(export.MNTv3),
+			strconv.FormatBool(export.NLMv4), strconv.FormatBool(export.RQUOTA),
+			strconv.FormatBool(export.NFSv40), strconv.FormatBool(export.NFSv41),
+			strconv.FormatBool(export.NFSv42), strconv.FormatBool(export.Plan9))
+		ch <- prometheus.MustNewConstMetric(
+			exportLastActivityDesc,
+			prometheus.GaugeValue,
+			float64(export.LastTime.Nano())/1e9,
+			exportid, path)
+		if ic.nfsv3 {
+			stats, err := result.nfsv3, result.nfsv3Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv3IO(%d)", export.ExportID))
+			} else if ic.emit(export.NFSv3, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV3QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", exportid, path))
 			}
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Requested),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Transfered),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Total),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Errors),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Latency)/1e9,
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.QueueWait)/1e9,
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Requested),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Transfered),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Total),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Errors),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Latency)/1e9,
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV3QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.QueueWait)/1e9,
-				"write", exportid, path)
 		}
-		if *ic.nfsv40 {
-			stats := dbus.BasicStats{}
-			if export.NFSv40 {
-				stats = ic.exportMgr.GetNFSv40IO(export.ExportID)
+		if ic.nfsv40 {
+			stats, err := result.nfsv40, result.nfsv40Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv40IO(%d)", export.ExportID))
+			} else if ic.emit(export.NFSv40, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV40QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", exportid, path))
 			}
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Requested),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Transfered),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Total),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Errors),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Latency)/1e9,
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.QueueWait)/1e9,
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Requested),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Transfered),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Total),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Errors),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Latency)/1e9,
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV40QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.QueueWait)/1e9,
-				"write", exportid, path)
 		}
-		if *ic.nfsv41 {
-			stats := dbus.BasicStats{}
-			if export.NFSv41 {
-				stats = ic.exportMgr.GetNFSv41IO(export.ExportID)
+		if ic.nfsv41 {
+			stats, err := result.nfsv41, result.nfsv41Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv41IO(%d)", export.ExportID))
+			} else if ic.emit(export.NFSv41, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV41QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", exportid, path))
+				if stats.Extended {
+					for _, op := range []struct {
+						name string
+						stat dbus.OperationStat
+					}{
+						{"open", stats.Open},
+						{"close", stats.Close},
+						{"getattr", stats.Getattr},
+						{"lock", stats.Lock},
+					} {
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							nfsV41OpsDesc,
+							prometheus.CounterValue,
+							float64(op.stat.Total),
+							op.name, exportid, path))
+						ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+							nfsV41OpsErrorsDesc,
+							prometheus.CounterValue,
+							float64(op.stat.Errors),
+							op.name, exportid, path))
+					}
+				}
 			}
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Requested),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Transfered),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Total),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Errors),
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.Latency)/1e9,
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Read.QueueWait)/1e9,
-				"read", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41RequestedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Requested),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41TransferedDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Transfered),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41OperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Total),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41ErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Errors),
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41LatencyDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.Latency)/1e9,
-				"write", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				nfsV41QueueWaitDesc,
-				prometheus.CounterValue,
-				float64(stats.Write.QueueWait)/1e9,
-				"write", exportid, path)
 		}
-		if *ic.pnfsv41 {
-			stats := dbus.PNFSOperations{}
-			if export.NFSv41 {
-				stats = ic.exportMgr.GetNFSv41Layouts(export.ExportID)
+		if ic.pnfsv41 {
+			stats, err := result.pnfsv41, result.pnfsv41Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv41Layouts(%d)", export.ExportID))
+			} else if ic.emit(export.NFSv41, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Total),
+					"getdevinfo", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Errors),
+					"getdevinfo", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Delays)/1e9,
+					"getdevinfo", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Total),
+					"get", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Errors),
+					"get", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Delays)/1e9,
+					"get", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Total),
+					"commit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Errors),
+					"commit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Delays)/1e9,
+					"commit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Total),
+					"return", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Errors),
+					"return", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Delays)/1e9,
+					"return", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Total),
+					"recall", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Errors),
+					"recall", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsLayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Delays)/1e9,
+					"recall", exportid, path))
 			}
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.Getdevinfo.Total),
-				"getdevinfo", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.Getdevinfo.Errors),
-				"getdevinfo", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.Getdevinfo.Delays)/1e9,
-				"getdevinfo", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutGet.Total),
-				"get", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutGet.Errors),
-				"get", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutGet.Delays)/1e9,
-				"get", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutCommit.Total),
-				"commit", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutCommit.Errors),
-				"commit", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutCommit.Delays)/1e9,
-				"commit", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutReturn.Total),
-				"return", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutReturn.Errors),
-				"return", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutReturn.Delays)/1e9,
-				"return", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutOperationsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutRecall.Total),
-				"recall", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutErrorsDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutRecall.Errors),
-				"recall", exportid, path)
-			ch <- prometheus.MustNewConstMetric(
-				pnfsLayoutDelayDesc,
-				prometheus.CounterValue,
-				float64(stats.LayoutRecall.Delays)/1e9,
-				"recall", exportid, path)
+		}
+		if ic.nfsv42 {
+			stats, err := result.nfsv42, result.nfsv42Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv42IO(%d)", export.ExportID))
+			} else if ic.emit(export.NFSv42, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					nfsV42QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", exportid, path))
+			}
+		}
+		if ic.pnfsv42 {
+			stats, err := result.pnfsv42, result.pnfsv42Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetNFSv42Layouts(%d)", export.ExportID))
+			} else if ic.emit(export.NFSv42, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Total),
+					"getdevinfo", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Errors),
+					"getdevinfo", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.Getdevinfo.Delays)/1e9,
+					"getdevinfo", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Total),
+					"get", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Errors),
+					"get", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutGet.Delays)/1e9,
+					"get", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Total),
+					"commit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Errors),
+					"commit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutCommit.Delays)/1e9,
+					"commit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Total),
+					"return", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Errors),
+					"return", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutReturn.Delays)/1e9,
+					"return", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutOperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Total),
+					"recall", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Errors),
+					"recall", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					pnfsV42LayoutDelayDesc,
+					prometheus.CounterValue,
+					float64(stats.LayoutRecall.Delays)/1e9,
+					"recall", exportid, path))
+			}
+		}
+		if ic.plan9 {
+			stats, err := result.plan9, result.plan9Err
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("Get9pIO(%d)", export.ExportID))
+			} else if ic.emit(export.Plan9, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Requested),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Transfered),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Total),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Errors),
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.Latency)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Read.QueueWait)/1e9,
+					"read", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9RequestedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Requested),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransferedDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Transfered),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9OperationsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Total),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9ErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Errors),
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9LatencyDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.Latency)/1e9,
+					"write", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9QueueWaitDesc,
+					prometheus.CounterValue,
+					float64(stats.Write.QueueWait)/1e9,
+					"write", exportid, path))
+			}
+		}
+		if ic.plan9 {
+			stats, err := result.plan9Trans, result.plan9TransErr
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("Get9pTransOps(%d)", export.ExportID))
+			} else if ic.emit(export.Plan9, stats.StatsBaseAnswer) {
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransportBytesDesc,
+					prometheus.CounterValue,
+					float64(stats.Transport.RxBytes),
+					"receive", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransportPacketsDesc,
+					prometheus.CounterValue,
+					float64(stats.Transport.RxPackets),
+					"receive", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransportErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Transport.RxErrors),
+					"receive", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransportBytesDesc,
+					prometheus.CounterValue,
+					float64(stats.Transport.TxBytes),
+					"transmit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransportPacketsDesc,
+					prometheus.CounterValue,
+					float64(stats.Transport.TxPackets),
+					"transmit", exportid, path))
+				ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+					plan9TransportErrorsDesc,
+					prometheus.CounterValue,
+					float64(stats.Transport.TxErrors),
+					"transmit", exportid, path))
+			}
+		}
+		if ic.mnt || ic.nlm || ic.rquota {
+			stats, err := result.ops, result.opsErr
+			if err != nil {
+				countError(ic.errors, err, fmt.Sprintf("GetTotalOPS(%d)", export.ExportID))
+			} else if ic.emit(export.MNTv3 || export.NLMv4 || export.RQUOTA, stats.StatsBaseAnswer) {
+				// a protocol missing from the answer is not counted by this
+				// ganesha, no series is made up for it
+				if ops, ok := protocolOps(stats.Ops, "MNT"); ok && ic.mnt && ic.active(export.MNTv3) {
+					ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+						mntOperationsDesc,
+						prometheus.CounterValue,
+						float64(ops),
+						exportid, path))
+				}
+				if ops, ok := protocolOps(stats.Ops, "NLM"); ok && ic.nlm && ic.active(export.NLMv4) {
+					ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+						nlmOperationsDesc,
+						prometheus.CounterValue,
+						float64(ops),
+						exportid, path))
+				}
+				if ops, ok := protocolOps(stats.Ops, "RQUOTA"); ok && ic.rquota && ic.active(export.RQUOTA) {
+					ch <- ic.stamp(stats.Time, prometheus.MustNewConstMetric(
+						rquotaOperationsDesc,
+						prometheus.CounterValue,
+						float64(ops),
+						exportid, path))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// fetch gets the statistics of every enabled protocol family served by export
+func (ic ExportsCollector) fetch(ctx context.Context, export dbus.Export) exportStats {
+	result := exportStats{}
+	if ic.nfsv3 && export.NFSv3 {
+		result.nfsv3, result.nfsv3Err = ic.exportMgr.GetNFSv3IO(ctx, export.ExportID)
+	}
+	if ic.nfsv40 && export.NFSv40 {
+		result.nfsv40, result.nfsv40Err = ic.exportMgr.GetNFSv40IO(ctx, export.ExportID)
+	}
+	if ic.nfsv41 && export.NFSv41 {
+		result.nfsv41, result.nfsv41Err = ic.exportMgr.GetNFSv41IO(ctx, export.ExportID)
+	}
+	if ic.pnfsv41 && export.NFSv41 {
+		result.pnfsv41, result.pnfsv41Err = ic.exportMgr.GetNFSv41Layouts(ctx, export.ExportID)
+	}
+	if ic.nfsv42 && export.NFSv42 {
+		result.nfsv42, result.nfsv42Err = ic.exportMgr.GetNFSv42IO(ctx, export.ExportID)
+	}
+	if ic.pnfsv42 && export.NFSv42 {
+		result.pnfsv42, result.pnfsv42Err = ic.exportMgr.GetNFSv42Layouts(ctx, export.ExportID)
+	}
+	if ic.plan9 && export.Plan9 {
+		result.plan9, result.plan9Err = ic.exportMgr.Get9pIO(ctx, export.ExportID)
+		result.plan9Trans, result.plan9TransErr = ic.exportMgr.Get9pTransOps(ctx, export.ExportID)
+	}
+	if (ic.mnt && export.MNTv3) || (ic.nlm && export.NLMv4) || (ic.rquota && export.RQUOTA) {
+		result.ops, result.opsErr = ic.exportMgr.GetTotalOPS(ctx, export.ExportID)
+	}
+	return result
+}
+
+// protocolOps sums the operations of every version of protocol, and tells
+// whether ganesha reported any
+func protocolOps(ops map[string]uint64, protocol string) (uint64, bool) {
+	var total uint64
+	found := false
+	for name, n := range ops {
+		if strings.HasPrefix(strings.ToUpper(name), protocol) {
+			total += n
+			found = true
 		}
 	}
+	return total, found
 }
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"context"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	fullV3OperationsDesc = prometheus.NewDesc(
+		"ganesha_nfs_v3_op_total",
+		"Number of NFSv3 operations",
+		[]string{"operation"}, nil,
+	)
+	fullV3ErrorsDesc = prometheus.NewDesc(
+		"ganesha_nfs_v3_op_errors_total",
+		"Number of NFSv3 operations in error",
+		[]string{"operation"}, nil,
+	)
+	fullV3DupsDesc = prometheus.NewDesc(
+		"ganesha_nfs_v3_op_dups_total",
+		"Number of duplicated NFSv3 requests",
+		[]string{"operation"}, nil,
+	)
+	fullV3LatencyAvgDesc = prometheus.NewDesc(
+		"ganesha_nfs_v3_op_latency_avg_seconds",
+		"Average latency of NFSv3 operations",
+		[]string{"operation"}, nil,
+	)
+	fullV3LatencyMinDesc = prometheus.NewDesc(
+		"ganesha_nfs_v3_op_latency_min_seconds",
+		"Minimum latency of NFSv3 operations",
+		[]string{"operation"}, nil,
+	)
+	fullV3LatencyMaxDesc = prometheus.NewDesc(
+		"ganesha_nfs_v3_op_latency_max_seconds",
+		"Maximum latency of NFSv3 operations",
+		[]string{"operation"}, nil,
+	)
+	fullV4OperationsDesc = prometheus.NewDesc(
+		"ganesha_nfs_v4_op_total",
+		"Number of NFSv4 operations",
+		[]string{"operation"}, nil,
+	)
+	fullV4ErrorsDesc = prometheus.NewDesc(
+		"ganesha_nfs_v4_op_errors_total",
+		"Number of NFSv4 operations in error",
+		[]string{"operation"}, nil,
+	)
+	fullV4LatencyAvgDesc = prometheus.NewDesc(
+		"ganesha_nfs_v4_op_latency_avg_seconds",
+		"Average latency of NFSv4 operations",
+		[]string{"operation"}, nil,
+	)
+	fullV4LatencyMinDesc = prometheus.NewDesc(
+		"ganesha_nfs_v4_op_latency_min_seconds",
+		"Minimum latency of NFSv4 operations",
+		[]string{"operation"}, nil,
+	)
+	fullV4LatencyMaxDesc = prometheus.NewDesc(
+		"ganesha_nfs_v4_op_latency_max_seconds",
+		"Maximum latency of NFSv4 operations",
+		[]string{"operation"}, nil,
+	)
+)
+
+var (
+	fullstatsEnabled = kingpin.Flag("collector.fullstats", "Activate per operation NFSv3 and NFSv4 stats collector").Default("true").Bool()
+)
+
+// FullStatsCollector Collector for the server-wide statistics of every
+// NFSv3 and NFSv4 operation
+type FullStatsCollector struct {
+	exportMgr dbus.ExportMgr
+	v3, v4    bool
+	errors    prometheus.Counter
+}
+
+// NewFullStatsCollector creates a new collector fetching the NFSv3 and
+// NFSv4 statistics when v3 and v4 are set
+func NewFullStatsCollector(conn *dbus.Conn, v3, v4 bool) FullStatsCollector {
+	return FullStatsCollector{
+		exportMgr: dbus.NewExportMgr(conn),
+		v3:        v3,
+		v4:        v4,
+		errors:    newDBusErrorsCounter("fullstats"),
+	}
+}
+
+// Describe prometheus description
+func (fc FullStatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- fullV3OperationsDesc
+	ch <- fullV3ErrorsDesc
+	ch <- fullV3DupsDesc
+	ch <- fullV3LatencyAvgDesc
+	ch <- fullV3LatencyMinDesc
+	ch <- fullV3LatencyMaxDesc
+	ch <- fullV4OperationsDesc
+	ch <- fullV4ErrorsDesc
+	ch <- fullV4LatencyAvgDesc
+	ch <- fullV4LatencyMinDesc
+	ch <- fullV4LatencyMaxDesc
+	fc.errors.Describe(ch)
+}
+
+// CollectErrors sends the error counters
+func (fc FullStatsCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	fc.errors.Collect(ch)
+}
+
+// Update do the actual job
+func (fc FullStatsCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	// NFSv3 and NFSv4 are fetched independently, one failing does not
+	// prevent the other from being collected
+	var v3Err, v4Err error
+	if fc.v3 {
+		v3Err = fc.updateV3(ctx, ch)
+	}
+	if fc.v4 {
+		v4Err = fc.updateV4(ctx, ch)
+	}
+	if v3Err != nil {
+		return v3Err
+	}
+	return v4Err
+}
+
+func (fc FullStatsCollector) updateV3(ctx context.Context, ch chan<- prometheus.Metric) error {
+	v3, err := fc.exportMgr.GetFULLV3Stats(ctx)
+	if err != nil {
+		countError(fc.errors, err, "GetFULLV3Stats")
+		return err
+	}
+	// ganesha answers with a false status while full stats are disabled
+	if !v3.Status {
+		log.Debugf("GetFULLV3Stats: %s", v3.Error)
+	}
+	for _, op := range v3.Ops {
+		ch <- prometheus.MustNewConstMetric(
+			fullV3OperationsDesc,
+			prometheus.CounterValue,
+			float64(op.Total),
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV3ErrorsDesc,
+			prometheus.CounterValue,
+			float64(op.Errors),
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV3DupsDesc,
+			prometheus.CounterValue,
+			float64(op.Dups),
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV3LatencyAvgDesc,
+			prometheus.GaugeValue,
+			op.Avg/1e3,
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV3LatencyMinDesc,
+			prometheus.GaugeValue,
+			op.Min/1e3,
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV3LatencyMaxDesc,
+			prometheus.GaugeValue,
+			op.Max/1e3,
+			op.Name)
+	}
+	return nil
+}
+
+func (fc FullStatsCollector) updateV4(ctx context.Context, ch chan<- prometheus.Metric) error {
+	v4, err := fc.exportMgr.GetFULLV4Stats(ctx)
+	if err != nil {
+		countError(fc.errors, err, "GetFULLV4Stats")
+		return err
+	}
+	if !v4.Status {
+		log.Debugf("GetFULLV4Stats: %s", v4.Error)
+	}
+	for _, op := range v4.Ops {
+		ch <- prometheus.MustNewConstMetric(
+			fullV4OperationsDesc,
+			prometheus.CounterValue,
+			float64(op.Total),
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV4ErrorsDesc,
+			prometheus.CounterValue,
+			float64(op.Errors),
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV4LatencyAvgDesc,
+			prometheus.GaugeValue,
+			op.Avg/1e3,
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV4LatencyMinDesc,
+			prometheus.GaugeValue,
+			op.Min/1e3,
+			op.Name)
+		ch <- prometheus.MustNewConstMetric(
+			fullV4LatencyMaxDesc,
+			prometheus.GaugeValue,
+			op.Max/1e3,
+			op.Name)
+	}
+	return nil
+}
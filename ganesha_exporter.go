@@ -2,51 +2,126 @@ package main
 
 import (
 	"github.com/Gandi/ganesha_exporter/dbus"
-	"github.com/davecgh/go-spew/spew"
 	"github.com/prometheus/client_golang/prometheus"
-	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/common/log"
 	"github.com/prometheus/common/version"
 	"gopkg.in/alecthomas/kingpin.v2"
 	"net/http"
+	"sync"
 )
 
 func main() {
 	var (
-		listenAddress     = kingpin.Flag("web.listen-address", "Address on which to expose metrics and web interface.").Default(":9587").String()
-		metricsPath       = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
-		gandi             = kingpin.Flag("gandi", "Activate Gandi specific fields").Default("false").Bool()
-		exporterCollector = kingpin.Flag("collector.exports", "Activate exports collector").Default("true").Bool()
+		serveCmd      = kingpin.Command("serve", "Serve the ganesha metrics, the default command").Default()
+		listenAddress = kingpin.Flag("web.listen-address", "Address on which to expose metrics and web interface.").Default(":9587").String()
+		metricsPath   = kingpin.Flag("web.telemetry-path", "Path under which to expose metrics.").Default("/metrics").String()
+		gandi         = kingpin.Flag("gandi", "Deprecated, Gandi specific fields are detected from the replies").Hidden().Default("false").Bool()
+		dbusBus       = kingpin.Flag("dbus.bus", "Well-known bus ganesha is registered on, used when no address is given").Default("system").Enum("system", "session")
+		dbusAddress   = kingpin.Flag("dbus.address", "Address of the bus ganesha is registered on, e.g. unix:path=/run/ganesha/bus or tcp:host=10.0.0.1,port=12345").Default("").String()
+		dbusService   = kingpin.Flag("dbus.service", "Bus name owned by ganesha").Default(dbus.ServiceName).String()
+		timeoutOffset = kingpin.Flag("web.timeout-offset", "Offset to subtract from the Prometheus scrape timeout, to leave time to send partial results").Default("500ms").Duration()
+		enableStats   = kingpin.Flag("ganesha.enable-stats", "Comma separated statistics types to enable in ganesha on connection, among all, nfs, fsal, v3_full, v4_full, auth and client_all_ops").Default("").String()
+		cacheInterval = kingpin.Flag("cache.interval", "Poll ganesha in the background on this interval and serve metrics from memory, 0 polls on every scrape").Default("0s").Duration()
+		moduleSpecs   = kingpin.Flag("probe.module", "Module usable by /probe, as NAME=FAMILY[,FAMILY...] with FAMILY one of nfsv3, nfsv40, nfsv41, pnfsv41, nfsv42, pnfsv42, 9p, mnt, nlm or rquota. Can be repeated, the default module collects every family but pnfsv42").Strings()
+		targetSpecs   = kingpin.Flag("target", "Ganesha instance to monitor, as NAME=[SERVICE@]ADDRESS where ADDRESS is a D-Bus address, system or session. Can be repeated, series are then labelled with instance=NAME").Strings()
 	)
-	ec := NewExportsCollector()
-	var clientCollector = kingpin.Flag("collector.clients", "Activate clients collector").Default("true").Bool()
-	cc := NewClientsCollector()
 
 	log.AddFlags(kingpin.CommandLine)
 	kingpin.Version(version.Print("ctld_exporter"))
 	kingpin.HelpFlag.Short('h')
-	kingpin.Parse()
+	command := kingpin.Parse()
 
-	dbus.Gandi = *gandi
+	if command != serveCmd.FullCommand() {
+		conn := dbus.NewConn(*dbusBus, *dbusAddress, *dbusService)
+		err := runCtl(command, conn)
+		conn.Close()
+		kingpin.FatalIfError(err, "%s", command)
+		return
+	}
+
+	if *gandi {
+		log.Warnln("--gandi is deprecated, Gandi specific fields are detected from the replies")
+	}
+	targets, err := parseTargets(*targetSpecs)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	modules, err := parseModules(*moduleSpecs)
+	if err != nil {
+		log.Fatalln(err)
+	}
+	statsTypes, err := parseStatsTypes(*enableStats)
+	if err != nil {
+		log.Fatalln(err)
+	}
 
 	reg := prometheus.NewPedanticRegistry()
 	reg.MustRegister(
 		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
 		prometheus.NewGoCollector(),
 	)
-	if *exporterCollector {
-		reg.MustRegister(ec)
+	conns := map[string]*dbus.Conn{}
+	if len(targets) == 0 {
+		conns[""] = dbus.NewConn(*dbusBus, *dbusAddress, *dbusService)
+	}
+	for _, t := range targets {
+		conns[t.name] = dbus.NewConn(t.bus, t.address, t.service)
 	}
-	if *clientCollector {
-		reg.MustRegister(cc)
+	collectors := map[string]GaneshaCollector{}
+	mu := sync.Mutex{}
+	wg := sync.WaitGroup{}
+	wg.Add(len(conns))
+	// targets are introspected concurrently, one that does not answer only
+	// delays the start by introspectTimeout
+	for name, conn := range conns {
+		go func(name string, conn *dbus.Conn) {
+			defer wg.Done()
+			if len(statsTypes) > 0 {
+				enableStatsOnConnect(conn, statsTypes)
+				conn.Connect()
+			}
+			logger := log.Base()
+			if name != "" {
+				logger = log.With("instance", name)
+			}
+			caps := discoverCapabilities(logger, conn)
+			gc := NewGaneshaCollector(conn, setupCollectors(logger, conn, caps))
+			if caps != nil {
+				instanceRegisterer(reg, name).MustRegister(capabilityCollector{caps: caps})
+			} else {
+				// collectors are restricted once ganesha can be introspected
+				rediscoverCapabilities(logger, conn, func(caps dbus.Capabilities) {
+					gc.SetCollectors(setupCollectors(logger, conn, caps))
+					instanceRegisterer(reg, name).MustRegister(capabilityCollector{caps: caps})
+				})
+			}
+			mu.Lock()
+			collectors[name] = gc
+			mu.Unlock()
+		}(name, conn)
 	}
-	http.Handle(*metricsPath, promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	wg.Wait()
+	if *cacheInterval > 0 {
+		for name, gc := range collectors {
+			instanceRegisterer(reg, name).MustRegister(NewCachedCollector(gc, *cacheInterval))
+		}
+		collectors = nil
+	}
+	http.Handle(*metricsPath, metricsHandler{
+		base:       reg,
+		collectors: collectors,
+		offset:     *timeoutOffset,
+	})
+	http.HandleFunc("/probe", func(w http.ResponseWriter, r *http.Request) {
+		probeHandler(w, r, modules, *timeoutOffset)
+	})
 	http.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
 		_, err := w.Write([]byte(`<html>
 			<head><title>ctld Exporter</title></head>
 			<body>
 			<h1>ctld Exporter</h1>
 			<p><a href="` + *metricsPath + `">Metrics</a></p>
+			<p><a href="/probe?target=system">Probe the system bus</a></p>
 			</body>
 			</html>`))
 		if err != nil {
@@ -56,7 +131,54 @@ func main() {
 
 	log.Infoln("Listening on", *listenAddress)
 	log.Fatal(http.ListenAndServe(*listenAddress, nil))
-	mgr := dbus.NewExportMgr()
-	time, exports := mgr.ShowExports()
-	spew.Dump(time, exports)
+}
+
+// setupCollectors creates the enabled collectors for the ganesha behind
+// conn, restricted to the methods it implements when caps are known
+func setupCollectors(logger log.Logger, conn *dbus.Conn, caps dbus.Capabilities) map[string]Collector {
+	exports, clients := exportsProtocols(), clientsProtocols()
+	if caps != nil {
+		restrictProtocols(logger, "exports", &exports, exportsFamilyMethods, caps)
+		restrictProtocols(logger, "clients", &clients, clientsFamilyMethods, caps)
+	}
+	collectors := newCollectors(conn, exports, clients, caps)
+	if caps != nil {
+		restrictCollectors(logger, collectors, caps)
+	}
+	return collectors
+}
+
+// newCollectors creates the enabled collectors for the ganesha behind conn,
+// skipping the optional calls missing from caps unless they are nil
+func newCollectors(conn *dbus.Conn, exports, clients protocols, caps dbus.Capabilities) map[string]Collector {
+	collectors := map[string]Collector{}
+	if *exportsEnabled {
+		collectors["exports"] = NewExportsCollector(conn, exports, *collectorWorkers, *collectorActiveOnly, *collectorSampleTime)
+	}
+	if *clientsEnabled {
+		collectors["clients"] = NewClientsCollector(conn, clients, *collectorWorkers, *collectorActiveOnly, *collectorSampleTime)
+	}
+	if *fullstatsEnabled {
+		collectors["fullstats"] = NewFullStatsCollector(conn,
+			implements(caps, "org.ganesha.nfsd.exportstats.GetFULLV3Stats"),
+			implements(caps, "org.ganesha.nfsd.exportstats.GetFULLV4Stats"))
+	}
+	if *globalEnabled {
+		collectors["global"] = NewGlobalCollector(conn,
+			implements(caps, "org.ganesha.nfsd.exportstats.GetGlobalOPS"),
+			implements(caps, "org.ganesha.nfsd.exportstats.ShowFastOPS"))
+	}
+	if *mdcacheEnabled {
+		collectors["mdcache"] = NewMDCacheCollector(conn)
+	}
+	if *authEnabled {
+		collectors["auth"] = NewAuthCollector(conn)
+	}
+	if *statsEnabled {
+		collectors["stats"] = NewStatsCollector(conn)
+	}
+	if *adminEnabled {
+		collectors["admin"] = NewAdminCollector(conn, implements(caps, graceMethod))
+	}
+	return collectors
 }
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	globalOperationsDesc = prometheus.NewDesc(
+		"ganesha_global_operations_total",
+		"Number of operations served for every protocol version",
+		[]string{"protocol"}, nil,
+	)
+	globalFastOperationsDesc = prometheus.NewDesc(
+		"ganesha_global_fast_operations_total",
+		"Number of operations served, as counted by the fast stats",
+		[]string{"protocol", "operation"}, nil,
+	)
+)
+
+var (
+	globalEnabled = kingpin.Flag("collector.global", "Activate server-wide operations collector").Default("true").Bool()
+)
+
+// GlobalCollector Collector for the server-wide operation counters, whose
+// cost does not depend on the number of exports or clients
+type GlobalCollector struct {
+	statsMgr     dbus.StatsMgr
+	global, fast bool
+	errors       prometheus.Counter
+}
+
+// NewGlobalCollector creates a new collector fetching the global and fast
+// stats when global and fast are set
+func NewGlobalCollector(conn *dbus.Conn, global, fast bool) GlobalCollector {
+	return GlobalCollector{
+		statsMgr: dbus.NewStatsMgr(conn),
+		global:   global,
+		fast:     fast,
+		errors:   newDBusErrorsCounter("global"),
+	}
+}
+
+// Describe prometheus description
+func (gc GlobalCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- globalOperationsDesc
+	ch <- globalFastOperationsDesc
+	gc.errors.Describe(ch)
+}
+
+// CollectErrors sends the error counters
+func (gc GlobalCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	gc.errors.Collect(ch)
+}
+
+// Update do the actual job
+func (gc GlobalCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	// the global and fast stats are fetched independently, one failing does
+	// not prevent the other from being collected
+	var globalErr, fastErr error
+	if gc.global {
+		globalErr = gc.updateGlobal(ctx, ch)
+	}
+	if gc.fast {
+		fastErr = gc.updateFast(ctx, ch)
+	}
+	if globalErr != nil {
+		return globalErr
+	}
+	return fastErr
+}
+
+func (gc GlobalCollector) updateGlobal(ctx context.Context, ch chan<- prometheus.Metric) error {
+	global, err := gc.statsMgr.GetGlobalOPS(ctx)
+	if err != nil {
+		countError(gc.errors, err, "GetGlobalOPS")
+		return err
+	}
+	// ganesha answers with a false status while it has no statistics to report
+	if !global.Status {
+		log.Debugf("GetGlobalOPS: %s", global.Error)
+	}
+	for protocol, total := range global.Ops {
+		ch <- prometheus.MustNewConstMetric(
+			globalOperationsDesc,
+			prometheus.CounterValue,
+			float64(total),
+			protocol)
+	}
+	return nil
+}
+
+func (gc GlobalCollector) updateFast(ctx context.Context, ch chan<- prometheus.Metric) error {
+	fast, err := gc.statsMgr.ShowFastOPS(ctx)
+	if err != nil {
+		countError(gc.errors, err, "ShowFastOPS")
+		return err
+	}
+	if !fast.Status {
+		log.Debugf("ShowFastOPS: %s", fast.Error)
+	}
+	for _, op := range fast.Ops {
+		ch <- prometheus.MustNewConstMetric(
+			globalFastOperationsDesc,
+			prometheus.CounterValue,
+			float64(op.Total),
+			op.Protocol, op.Name)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/log"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// defaultScrapeTimeout is the Prometheus default, used when the scrape
+// timeout is not announced
+const defaultScrapeTimeout = 10 * time.Second
+
+// scrapeContext returns a context bound to the scrape timeout announced by
+// Prometheus, minus offset so that partial results are sent in time
+func scrapeContext(r *http.Request, offset time.Duration) (context.Context, context.CancelFunc) {
+	timeout := scrapeTimeout(r.Header.Get("X-Prometheus-Scrape-Timeout-Seconds"), offset)
+	return context.WithTimeout(r.Context(), timeout)
+}
+
+// scrapeTimeout returns the time left to collect metrics from the scrape
+// timeout header, falling back to defaultScrapeTimeout when it is missing
+// or invalid. The offset is only subtracted from timeouts longer than it
+func scrapeTimeout(header string, offset time.Duration) time.Duration {
+	timeout := defaultScrapeTimeout
+	if header != "" {
+		seconds, err := strconv.ParseFloat(header, 64)
+		if err != nil || !(seconds > 0) || math.IsInf(seconds, 1) {
+			log.Warnf("invalid scrape timeout %q, using %s", header, defaultScrapeTimeout)
+		} else {
+			timeout = time.Duration(seconds * float64(time.Second))
+		}
+	}
+	if timeout > offset {
+		timeout -= offset
+	}
+	return timeout
+}
+
+// metricsHandler serves the metrics of base along with the ones of every
+// ganesha collector, whose D-Bus calls are bound to the scrape timeout
+type metricsHandler struct {
+	base prometheus.Gatherer
+	// collectors are indexed by instance name, an empty name adds no label
+	collectors map[string]GaneshaCollector
+	offset     time.Duration
+}
+
+func (h metricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := scrapeContext(r, h.offset)
+	defer cancel()
+	// The registry runs every collector in its own goroutine, so instances
+	// are scraped concurrently
+	reg := prometheus.NewPedanticRegistry()
+	for name, gc := range h.collectors {
+		if err := instanceRegisterer(reg, name).Register(gc.WithContext(ctx)); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+	}
+	promhttp.HandlerFor(prometheus.Gatherers{h.base, reg}, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
+
+// instanceRegisterer returns a registerer labelling metrics with the name
+// of their ganesha instance, an empty name adds no label
+func instanceRegisterer(reg prometheus.Registerer, name string) prometheus.Registerer {
+	if name == "" {
+		return reg
+	}
+	return prometheus.WrapRegistererWith(prometheus.Labels{"instance": name}, reg)
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestScrapeTimeout(t *testing.T) {
+	offset := 500 * time.Millisecond
+	tests := []struct {
+		name   string
+		header string
+		want   time.Duration
+	}{
+		{"missing", "", defaultScrapeTimeout - offset},
+		{"invalid", "ten", defaultScrapeTimeout - offset},
+		{"negative", "-3", defaultScrapeTimeout - offset},
+		{"infinite", "+Inf", defaultScrapeTimeout - offset},
+		{"below offset", "0.3", 300 * time.Millisecond},
+		{"normal", "3", 2500 * time.Millisecond},
+		{"fractional", "1.5", time.Second},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := scrapeTimeout(tt.header, offset); got != tt.want {
+				t.Errorf("scrapeTimeout(%q) = %s, want %s", tt.header, got, tt.want)
+			}
+		})
+	}
+}
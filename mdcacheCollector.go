@@ -0,0 +1,136 @@
+package main
+
+import (
+	"context"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"gopkg.in/alecthomas/kingpin.v2"
+)
+
+var (
+	mdcacheRequestsDesc = prometheus.NewDesc(
+		"ganesha_mdcache_requests_total",
+		"Number of metadata cache lookups",
+		nil, nil,
+	)
+	mdcacheHitsDesc = prometheus.NewDesc(
+		"ganesha_mdcache_hits_total",
+		"Number of metadata cache hits",
+		nil, nil,
+	)
+	mdcacheMissesDesc = prometheus.NewDesc(
+		"ganesha_mdcache_misses_total",
+		"Number of metadata cache misses",
+		nil, nil,
+	)
+	mdcacheConflictsDesc = prometheus.NewDesc(
+		"ganesha_mdcache_conflicts_total",
+		"Number of metadata cache conflicts",
+		nil, nil,
+	)
+	mdcacheAddsDesc = prometheus.NewDesc(
+		"ganesha_mdcache_adds_total",
+		"Number of entries added to the metadata cache",
+		nil, nil,
+	)
+	mdcacheMappingsDesc = prometheus.NewDesc(
+		"ganesha_mdcache_mappings_total",
+		"Number of metadata cache mappings",
+		nil, nil,
+	)
+	mdcacheOpenFDsDesc = prometheus.NewDesc(
+		"ganesha_mdcache_open_fds",
+		"Number of file descriptors opened by the FSAL",
+		nil, nil,
+	)
+	mdcacheFDLimitDesc = prometheus.NewDesc(
+		"ganesha_mdcache_fd_limit",
+		"System limit on file descriptors",
+		nil, nil,
+	)
+	mdcacheFDUsageDesc = prometheus.NewDesc(
+		"ganesha_mdcache_fd_usage_info",
+		"File descriptors usage state, relative to the LRU water marks",
+		[]string{"state"}, nil,
+	)
+	mdcacheEntriesDesc = prometheus.NewDesc(
+		"ganesha_mdcache_lru_entries",
+		"Number of LRU entries in use",
+		nil, nil,
+	)
+	mdcacheChunksDesc = prometheus.NewDesc(
+		"ganesha_mdcache_lru_chunks",
+		"Number of directory chunks in use",
+		nil, nil,
+	)
+)
+
+var (
+	mdcacheEnabled = kingpin.Flag("collector.mdcache", "Activate metadata cache collector").Default("true").Bool()
+)
+
+// MDCacheCollector Collector for the ganesha metadata cache
+type MDCacheCollector struct {
+	statsMgr dbus.StatsMgr
+	errors   prometheus.Counter
+}
+
+// NewMDCacheCollector creates a new collector
+func NewMDCacheCollector(conn *dbus.Conn) MDCacheCollector {
+	return MDCacheCollector{
+		statsMgr: dbus.NewStatsMgr(conn),
+		errors:   newDBusErrorsCounter("mdcache"),
+	}
+}
+
+// Describe prometheus description
+func (mc MDCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- mdcacheRequestsDesc
+	ch <- mdcacheHitsDesc
+	ch <- mdcacheMissesDesc
+	ch <- mdcacheConflictsDesc
+	ch <- mdcacheAddsDesc
+	ch <- mdcacheMappingsDesc
+	ch <- mdcacheOpenFDsDesc
+	ch <- mdcacheFDLimitDesc
+	ch <- mdcacheFDUsageDesc
+	ch <- mdcacheEntriesDesc
+	ch <- mdcacheChunksDesc
+	mc.errors.Describe(ch)
+}
+
+// CollectErrors sends the error counters
+func (mc MDCacheCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	mc.errors.Collect(ch)
+}
+
+// Update do the actual job
+func (mc MDCacheCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	stats, err := mc.statsMgr.ShowMDCache(ctx)
+	if err != nil {
+		countError(mc.errors, err, "ShowMDCache")
+		return err
+	}
+	if !stats.Status {
+		log.Debugf("ShowMDCache: %s", stats.Error)
+		return nil
+	}
+	for _, name := range stats.Unknown {
+		log.Debugf("ShowMDCache: ignoring unknown cache statistic %q", name)
+	}
+	ch <- prometheus.MustNewConstMetric(mdcacheRequestsDesc, prometheus.CounterValue, float64(stats.Requests))
+	ch <- prometheus.MustNewConstMetric(mdcacheHitsDesc, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(mdcacheMissesDesc, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(mdcacheConflictsDesc, prometheus.CounterValue, float64(stats.Conflicts))
+	ch <- prometheus.MustNewConstMetric(mdcacheAddsDesc, prometheus.CounterValue, float64(stats.Adds))
+	ch <- prometheus.MustNewConstMetric(mdcacheMappingsDesc, prometheus.CounterValue, float64(stats.Mappings))
+	ch <- prometheus.MustNewConstMetric(mdcacheOpenFDsDesc, prometheus.GaugeValue, float64(stats.OpenFDs))
+	ch <- prometheus.MustNewConstMetric(mdcacheFDLimitDesc, prometheus.GaugeValue, float64(stats.FDLimit))
+	if stats.FDUsage != "" {
+		ch <- prometheus.MustNewConstMetric(mdcacheFDUsageDesc, prometheus.GaugeValue, 1, stats.FDUsage)
+	}
+	ch <- prometheus.MustNewConstMetric(mdcacheEntriesDesc, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(mdcacheChunksDesc, prometheus.GaugeValue, float64(stats.Chunks))
+	return nil
+}
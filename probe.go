@@ -0,0 +1,85 @@
+package main
+
+import (
+	"fmt"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/common/log"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const defaultModule = "default"
+
+// parseModules parses probe modules given as NAME=FAMILY[,FAMILY...], a
+// default module collecting every protocol family but pnfsv42 is always
+// available
+func parseModules(specs []string) (map[string]protocols, error) {
+	all := protocols{}
+	for _, enabled := range all.families() {
+		*enabled = true
+	}
+	// GetNFSv42Layouts is missing from most ganesha versions
+	all.pnfsv42 = false
+	modules := map[string]protocols{defaultModule: all}
+	for _, spec := range specs {
+		i := strings.Index(spec, "=")
+		if i <= 0 {
+			return nil, fmt.Errorf("invalid module %q, expected NAME=FAMILY[,FAMILY...]", spec)
+		}
+		name := spec[:i]
+		if _, ok := modules[name]; ok {
+			return nil, fmt.Errorf("duplicated module name %q", name)
+		}
+		p := protocols{}
+		families := p.families()
+		for _, family := range strings.Split(spec[i+1:], ",") {
+			enabled, ok := families[family]
+			if !ok {
+				return nil, fmt.Errorf("unknown protocol family %q in module %q", family, name)
+			}
+			*enabled = true
+		}
+		modules[name] = p
+	}
+	return modules, nil
+}
+
+// probeHandler collects the metrics of the ganesha given by the target
+// parameter, with the protocol families of the module parameter
+func probeHandler(w http.ResponseWriter, r *http.Request, modules map[string]protocols, offset time.Duration) {
+	params := r.URL.Query()
+	spec := params.Get("target")
+	if spec == "" {
+		http.Error(w, "Target parameter is missing", http.StatusBadRequest)
+		return
+	}
+	t, err := parseAddress(spec)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	moduleName := params.Get("module")
+	if moduleName == "" {
+		moduleName = defaultModule
+	}
+	p, ok := modules[moduleName]
+	if !ok {
+		http.Error(w, fmt.Sprintf("Unknown module %q", moduleName), http.StatusBadRequest)
+		return
+	}
+
+	ctx, cancel := scrapeContext(r, offset)
+	defer cancel()
+	conn := dbus.NewConn(t.bus, t.address, t.service)
+	defer func() {
+		if err := conn.Close(); err != nil {
+			log.Errorf("closing connection to %s: %v", spec, err)
+		}
+	}()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(NewGaneshaCollector(conn, newCollectors(conn, p, p, nil)).WithContext(ctx))
+	promhttp.HandlerFor(registry, promhttp.HandlerOpts{}).ServeHTTP(w, r)
+}
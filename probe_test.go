@@ -0,0 +1,62 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestParseModules(t *testing.T) {
+	all := protocols{
+		nfsv3: true, nfsv40: true, nfsv41: true, pnfsv41: true, nfsv42: true, plan9: true,
+		mnt: true, nlm: true, rquota: true,
+	}
+	tests := []struct {
+		name    string
+		specs   []string
+		want    map[string]protocols
+		wantErr bool
+	}{
+		{"default only", nil, map[string]protocols{defaultModule: all}, false},
+		{
+			"nfs",
+			[]string{"nfs=nfsv3,nfsv41"},
+			map[string]protocols{defaultModule: all, "nfs": {nfsv3: true, nfsv41: true}},
+			false,
+		},
+		{
+			"side protocols",
+			[]string{"side=mnt,nlm,rquota", "layouts=pnfsv41,pnfsv42"},
+			map[string]protocols{
+				defaultModule: all,
+				"side":        {mnt: true, nlm: true, rquota: true},
+				"layouts":     {pnfsv41: true, pnfsv42: true},
+			},
+			false,
+		},
+		{"9p", []string{"plan9=9p"}, map[string]protocols{defaultModule: all, "plan9": {plan9: true}}, false},
+		{"unknown family", []string{"nfs=nfsv5"}, nil, true},
+		{"empty family", []string{"nfs="}, nil, true},
+		{"missing name", []string{"=nfsv3"}, nil, true},
+		{"no equal sign", []string{"nfsv3"}, nil, true},
+		{"duplicated name", []string{"nfs=nfsv3", "nfs=nfsv40"}, nil, true},
+		{"default redefined", []string{"default=nfsv3"}, nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseModules(tt.specs)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseModules(%q) error = %v, wantErr %v", tt.specs, err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("parseModules(%q) = %+v, want %+v", tt.specs, got, tt.want)
+			}
+			for name, p := range tt.want {
+				if got[name] != p {
+					t.Errorf("parseModules(%q)[%q] = %+v, want %+v", tt.specs, name, got[name], p)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/log"
+	"gopkg.in/alecthomas/kingpin.v2"
+	"strings"
+	"time"
+)
+
+// enableStatsTimeout bounds the EnableStats calls made on connection
+const enableStatsTimeout = 10 * time.Second
+
+var (
+	statsEnabledDesc = prometheus.NewDesc(
+		"ganesha_stats_enabled",
+		"Whether ganesha counts a type of statistics",
+		[]string{"type"}, nil,
+	)
+)
+
+var (
+	statsEnabled = kingpin.Flag("collector.stats", "Activate statistics status collector").Default("true").Bool()
+)
+
+// StatsCollector Collector for the types of statistics ganesha counts
+type StatsCollector struct {
+	statsMgr dbus.StatsMgr
+	errors   prometheus.Counter
+}
+
+// NewStatsCollector creates a new collector
+func NewStatsCollector(conn *dbus.Conn) StatsCollector {
+	return StatsCollector{
+		statsMgr: dbus.NewStatsMgr(conn),
+		errors:   newDBusErrorsCounter("stats"),
+	}
+}
+
+// Describe prometheus description
+func (sc StatsCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- statsEnabledDesc
+	sc.errors.Describe(ch)
+}
+
+// CollectErrors sends the error counters
+func (sc StatsCollector) CollectErrors(ch chan<- prometheus.Metric) {
+	sc.errors.Collect(ch)
+}
+
+// Update do the actual job
+func (sc StatsCollector) Update(ctx context.Context, ch chan<- prometheus.Metric) error {
+	statuses, err := sc.statsMgr.StatusStats(ctx)
+	if err != nil {
+		countError(sc.errors, err, "StatusStats")
+		return err
+	}
+	for _, status := range statuses {
+		var enabled float64
+		if status.Enabled {
+			enabled = 1
+		}
+		ch <- prometheus.MustNewConstMetric(statsEnabledDesc, prometheus.GaugeValue, enabled, status.Type)
+	}
+	return nil
+}
+
+// parseStatsTypes parses a comma separated list of statistics types
+func parseStatsTypes(spec string) ([]string, error) {
+	var types []string
+	for _, t := range strings.Split(spec, ",") {
+		if t == "" {
+			continue
+		}
+		known := t == "all"
+		for _, statsType := range dbus.StatsTypes {
+			known = known || t == statsType
+		}
+		if !known {
+			return nil, fmt.Errorf("unknown statistics type %q, expected all or one of %s", t, strings.Join(dbus.StatsTypes, ", "))
+		}
+		types = append(types, t)
+	}
+	return types, nil
+}
+
+// enableStatsOnConnect enables counting the statistics types every time
+// conn connects, as ganesha forgets them when it restarts
+func enableStatsOnConnect(conn *dbus.Conn, types []string) {
+	statsMgr := dbus.NewStatsMgr(conn)
+	conn.OnConnect(func() {
+		ctx, cancel := context.WithTimeout(context.Background(), enableStatsTimeout)
+		defer cancel()
+		for _, t := range types {
+			if err := statsMgr.EnableStats(ctx, t); err != nil {
+				log.Errorf("enabling %s statistics: %v", t, err)
+			} else {
+				log.Infof("enabled %s statistics", t)
+			}
+		}
+	})
+}
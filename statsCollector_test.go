@@ -0,0 +1,33 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseStatsTypes(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    []string
+		wantErr bool
+	}{
+		{"empty", "", nil, false},
+		{"all", "all", []string{"all"}, false},
+		{"several", "nfs,v3_full,client_all_ops", []string{"nfs", "v3_full", "client_all_ops"}, false},
+		{"empty items", ",nfs,,auth,", []string{"nfs", "auth"}, false},
+		{"unknown", "nfs,v5_full", nil, true},
+		{"case sensitive", "NFS", nil, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseStatsTypes(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseStatsTypes(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseStatsTypes(%q) = %q, want %q", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
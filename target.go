@@ -0,0 +1,65 @@
+package main
+
+import (
+	"fmt"
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"strings"
+)
+
+// target is a ganesha instance monitored by the exporter
+type target struct {
+	name    string
+	bus     string
+	address string
+	service string
+}
+
+// parseTarget parses a target given as NAME=[SERVICE@]ADDRESS
+func parseTarget(s string) (target, error) {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return target{}, fmt.Errorf("invalid target %q, expected NAME=[SERVICE@]ADDRESS", s)
+	}
+	t, err := parseAddress(s[i+1:])
+	t.name = s[:i]
+	return t, err
+}
+
+// parseAddress parses a target address given as [SERVICE@]ADDRESS, where
+// ADDRESS is either a D-Bus address or the name of a well-known bus
+func parseAddress(s string) (target, error) {
+	t := target{service: dbus.ServiceName}
+	address := s
+	// bus names cannot contain ':' while every D-Bus address does
+	if at := strings.Index(address, "@"); at >= 0 && !strings.Contains(address[:at], ":") {
+		t.service = address[:at]
+		address = address[at+1:]
+	}
+	switch address {
+	case "":
+		return target{}, fmt.Errorf("invalid target address %q", s)
+	case "system", "session":
+		t.bus = address
+	default:
+		t.address = address
+	}
+	return t, nil
+}
+
+// parseTargets parses every target, rejecting duplicated names
+func parseTargets(specs []string) ([]target, error) {
+	targets := make([]target, 0, len(specs))
+	names := map[string]bool{}
+	for _, spec := range specs {
+		t, err := parseTarget(spec)
+		if err != nil {
+			return nil, err
+		}
+		if names[t.name] {
+			return nil, fmt.Errorf("duplicated target name %q", t.name)
+		}
+		names[t.name] = true
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
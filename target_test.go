@@ -0,0 +1,71 @@
+package main
+
+import (
+	"github.com/Gandi/ganesha_exporter/dbus"
+	"testing"
+)
+
+func TestParseTarget(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    target
+		wantErr bool
+	}{
+		{"system bus", "nfs1=system", target{name: "nfs1", bus: "system", service: dbus.ServiceName}, false},
+		{"session bus", "nfs1=session", target{name: "nfs1", bus: "session", service: dbus.ServiceName}, false},
+		{"address", "nfs1=unix:path=/run/ganesha/bus", target{name: "nfs1", address: "unix:path=/run/ganesha/bus", service: dbus.ServiceName}, false},
+		{"service", "nfs2=org.ganesha.nfsd2@system", target{name: "nfs2", bus: "system", service: "org.ganesha.nfsd2"}, false},
+		{"service and address", "nfs2=org.ganesha.nfsd2@tcp:host=10.0.0.1,port=12345", target{name: "nfs2", address: "tcp:host=10.0.0.1,port=12345", service: "org.ganesha.nfsd2"}, false},
+		{"address with @", "nfs1=unix:path=/run/ganesha@1/bus", target{name: "nfs1", address: "unix:path=/run/ganesha@1/bus", service: dbus.ServiceName}, false},
+		{"missing name", "=system", target{}, true},
+		{"missing address", "nfs1=", target{}, true},
+		{"missing address after service", "nfs1=org.ganesha.nfsd@", target{}, true},
+		{"no equal sign", "system", target{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseTarget(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTarget(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseTarget(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseAddress(t *testing.T) {
+	tests := []struct {
+		name    string
+		spec    string
+		want    target
+		wantErr bool
+	}{
+		{"system bus", "system", target{bus: "system", service: dbus.ServiceName}, false},
+		{"address", "unix:abstract=ganesha", target{address: "unix:abstract=ganesha", service: dbus.ServiceName}, false},
+		{"service", "org.ganesha.nfsd2@session", target{bus: "session", service: "org.ganesha.nfsd2"}, false},
+		{"empty", "", target{}, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseAddress(tt.spec)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseAddress(%q) error = %v, wantErr %v", tt.spec, err, tt.wantErr)
+			}
+			if !tt.wantErr && got != tt.want {
+				t.Errorf("parseAddress(%q) = %+v, want %+v", tt.spec, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseTargets(t *testing.T) {
+	if _, err := parseTargets([]string{"nfs1=system", "nfs2=session"}); err != nil {
+		t.Errorf("parseTargets() error = %v", err)
+	}
+	if _, err := parseTargets([]string{"nfs1=system", "nfs1=session"}); err == nil {
+		t.Errorf("parseTargets() accepted a duplicated name")
+	}
+}